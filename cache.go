@@ -0,0 +1,101 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"sync"
+)
+
+type lookupKey struct {
+	set *template.Template
+	s   Status
+}
+
+// lookupCache holds the result of template lookups per set and status,
+// including misses, as a Lookup walks the set's map for every candidate name.
+// Lookups are only cached in sets which were executed,
+// as html/template doesn't allow parsing into a set after execution.
+// Before that, templates may still be added, for example after TemplateFor.
+type lookupCache struct {
+	mu       sync.RWMutex
+	m        map[lookupKey]*template.Template
+	executed map[*template.Template]bool
+}
+
+func (c *lookupCache) load(set *template.Template, s Status) (*template.Template, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tmpl, ok := c.m[lookupKey{set, s}]
+	return tmpl, ok
+}
+
+// store caches tmpl for s in set, if set was executed.
+func (c *lookupCache) store(set *template.Template, s Status, tmpl *template.Template) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.executed[set] {
+		return
+	}
+	if c.m == nil {
+		c.m = make(map[lookupKey]*template.Template)
+	}
+	c.m[lookupKey{set, s}] = tmpl
+}
+
+func (c *lookupCache) isExecuted(set *template.Template) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.executed[set]
+}
+
+// setExecuted records that a template of set was executed.
+func (c *lookupCache) setExecuted(set *template.Template) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.executed == nil {
+		c.executed = make(map[*template.Template]bool)
+	}
+	c.executed[set] = true
+}
+
+// reset drops all cached lookups.
+func (c *lookupCache) reset() {
+	c.mu.Lock()
+	c.m = nil
+	c.executed = nil
+	c.mu.Unlock()
+}
+
+// executed records that tmpl of set was executed, when lookups in set are cacheable.
+// From then on, the set can't change.
+func (p *Pages) executed(set, tmpl *template.Template) {
+	if set == nil || p.lookups.isExecuted(set) || !p.cacheable(set) || set.Lookup(tmpl.Name()) != tmpl {
+		return
+	}
+	p.lookups.setExecuted(set)
+}
+
+// cacheable reports whether lookups in set can be cached:
+// it is Tmpl, the set from SetTemplate or one of Locales.
+// Sets passed to RenderUsing and sets reloaded by WatchFS are not cached, as they come and go.
+func (p *Pages) cacheable(set *template.Template) bool {
+	if set == nil {
+		return false
+	}
+	if set == p.Tmpl || set == p.swapped.Load() {
+		return true
+	}
+	for _, l := range p.Locales {
+		if set == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_cacheable(t *testing.T) {
+	var (
+		tmpl    = template.Must(template.New("error").Parse("Tmpl"))
+		swapped = template.Must(template.New("error").Parse("Swapped"))
+		locale  = template.Must(template.New("error").Parse("Locale"))
+		other   = template.Must(template.New("error").Parse("Other"))
+	)
+
+	p := &Pages{Tmpl: tmpl, Locales: map[string]*template.Template{"nl": locale}}
+	p.SetTemplate(swapped)
+
+	tests := []struct {
+		name string
+		set  *template.Template
+		want bool
+	}{
+		{"Tmpl", tmpl, true},
+		{"Swapped", swapped, true},
+		{"Locale", locale, true},
+		{"Other", other, false},
+		{"Nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.cacheable(tt.set); got != tt.want {
+				t.Errorf("Pages.cacheable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_lookupHTML_cache(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(`{{ define "error" }}error{{ end }}`))
+	p := &Pages{Tmpl: tmpl}
+
+	if name, _ := p.TemplateFor(http.StatusNotFound); name != "error" {
+		t.Errorf("Pages.TemplateFor() = %v, want error", name)
+	}
+	if _, ok := p.lookups.load(tmpl, http.StatusNotFound); ok {
+		t.Error("lookupCache.load() hit before the set was executed")
+	}
+
+	template.Must(tmpl.New("404").Parse("404"))
+	if name, _ := p.TemplateFor(http.StatusNotFound); name != "404" {
+		t.Errorf("Pages.TemplateFor() = %v, want 404 after parsing it", name)
+	}
+
+	if err := p.Render(httptest.NewRecorder(), &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := p.lookupHTML(tmpl, http.StatusNotFound); got != tmpl.Lookup("404") {
+			t.Errorf("Pages.lookupHTML() = %v, want 404", tmplName(got))
+		}
+		if got := p.lookupHTML(tmpl, http.StatusGone); got != tmpl.Lookup("error") {
+			t.Errorf("Pages.lookupHTML() = %v, want error", tmplName(got))
+		}
+	}
+
+	if got, ok := p.lookups.load(tmpl, http.StatusGone); !ok || got != tmpl.Lookup("error") {
+		t.Errorf("lookupCache.load() = %v, %v, want error, true", got, ok)
+	}
+
+	swapped := template.Must(template.New("").Parse(`{{ define "error" }}swapped{{ end }}`))
+	p.SetTemplate(swapped)
+
+	if _, ok := p.lookups.load(tmpl, http.StatusGone); ok {
+		t.Error("Pages.SetTemplate() did not reset the lookup cache")
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "swapped" {
+		t.Errorf("Pages.Render() = %q, want %q", got, "swapped")
+	}
+}
+
+// BenchmarkPages_lookupHTML compares lookups in Tmpl, which are cached,
+// to the same lookups in a set passed to RenderUsing, which are not.
+func BenchmarkPages_lookupHTML(b *testing.B) {
+	const text = `{{ define "404" }}404{{ end }}{{ define "5xx" }}5xx{{ end }}{{ define "error" }}error{{ end }}`
+
+	var (
+		tmpl  = template.Must(template.New("").Parse(text))
+		other = template.Must(template.New("").Parse(text))
+		p     = &Pages{Tmpl: tmpl}
+	)
+	if err := p.Render(httptest.NewRecorder(), &Data{Code: http.StatusNotFound}); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bb := range []struct {
+		name string
+		set  *template.Template
+	}{
+		{"cached", tmpl},
+		{"uncached", other},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				p.lookupHTML(bb.set, http.StatusNotFound)
+				p.lookupHTML(bb.set, http.StatusGone)
+				p.lookupHTML(bb.set, http.StatusBadGateway)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressMinBytes is used when Pages.CompressMinBytes is 0.
+// Smaller bodies gain little from compression, or even grow.
+const DefaultCompressMinBytes = 1024
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+// An explicit gzip entry takes precedence over "*".
+func acceptsGzip(acceptEncoding string) bool {
+	var (
+		accepted bool
+		explicit bool
+	)
+
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(coding))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case name == "gzip":
+			accepted, explicit = q > 0, true
+		case name == "*" && !explicit:
+			accepted = q > 0
+		}
+	}
+	return accepted
+}
+
+// compress reports whether a body of size bytes should be gzip compressed for dp.
+// It adds Accept-Encoding to the Vary header of h when the outcome depends on it.
+func (p *Pages) compress(h http.Header, dp Provider, size int) bool {
+	if p.DisableCompression || h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	min := p.CompressMinBytes
+	if min == 0 {
+		min = DefaultCompressMinBytes
+	}
+	if size < min {
+		return false
+	}
+
+	addVary(h, "Accept-Encoding")
+
+	r := dp.Request()
+	return r != nil && acceptsGzip(r.Header.Get("Accept-Encoding"))
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipBuffer replaces the contents of buf by its gzip compressed form.
+func gzipBuffer(buf *bytes.Buffer) error {
+	plain := buffers.Get()
+	defer buffers.Put(plain)
+
+	plain.Write(buf.Bytes())
+	buf.Reset()
+
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(buf)
+
+	if _, err := plain.WriteTo(zw); err != nil {
+		return fmt.Errorf("ehtml Render gzip: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("ehtml Render gzip: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"compress/gzip"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_acceptsGzip(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=1.0, *;q=0.5", true},
+		{"br, GZIP", true},
+		{"gzip;q=0", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false},
+		{"*, gzip;q=0", false},
+		{"identity", false},
+		{"gzip;q=x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := acceptsGzip(tt.accept); got != tt.want {
+				t.Errorf("acceptsGzip() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_compress(t *testing.T) {
+	long := strings.Repeat("Lorem ipsum dolor sit amet. ", 100)
+	tmpl := template.Must(template.New("error").Parse("{{ .Message }}"))
+
+	tests := []struct {
+		name     string
+		pages    *Pages
+		accept   string
+		msg      string
+		wantGzip bool
+		wantVary bool
+	}{
+		{
+			"Compressed",
+			&Pages{Tmpl: tmpl},
+			"gzip",
+			long,
+			true,
+			true,
+		},
+		{
+			"Not accepted",
+			&Pages{Tmpl: tmpl},
+			"",
+			long,
+			false,
+			true,
+		},
+		{
+			"Small",
+			&Pages{Tmpl: tmpl},
+			"gzip",
+			"Not found",
+			false,
+			false,
+		},
+		{
+			"CompressMinBytes",
+			&Pages{Tmpl: tmpl, CompressMinBytes: 5},
+			"gzip",
+			"Not found",
+			true,
+			true,
+		},
+		{
+			"DisableCompression",
+			&Pages{Tmpl: tmpl, DisableCompression: true},
+			"gzip",
+			long,
+			false,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			if err := tt.pages.Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: tt.msg}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Header().Get("Content-Encoding") == "gzip"; got != tt.wantGzip {
+				t.Errorf("Pages.Render() gzip = %v, want %v", got, tt.wantGzip)
+			}
+			if got := varies(w.Header(), "Accept-Encoding"); got != tt.wantVary {
+				t.Errorf("Pages.Render() Vary = %q, want %v", w.Header().Get("Vary"), tt.wantVary)
+			}
+
+			if got, want := w.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+				t.Errorf("Pages.Render() Content-Length = %v, want %v", got, want)
+			}
+
+			var body io.Reader = w.Body
+			if tt.wantGzip {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			}
+
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tt.msg {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.msg)
+			}
+		})
+	}
+}
+
+func TestPages_Render_compressHeaderProvider(t *testing.T) {
+	p := &Pages{
+		Tmpl:             template.Must(template.New("error").Parse("{{ .Message }}")),
+		CompressMinBytes: 1,
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	d := &Data{Req: r, Code: http.StatusNotFound, Msg: "Not found", Hdr: http.Header{"Content-Encoding": {"br"}}}
+	if err := p.Render(w, d); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "br" {
+		t.Errorf("Pages.Render() Content-Encoding = %q, want %q", got, "br")
+	}
+	if got := w.Body.String(); got != "Not found" {
+		t.Errorf("Pages.Render() = %q, want %q", got, "Not found")
+	}
+}
+
+func TestPages_Render_compressVary(t *testing.T) {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Message }}")), CompressMinBytes: 1}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Not found"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Header().Values("Vary"), []string{"Accept-Encoding", "Accept"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages.Render() Vary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RenderContext is like Render, but stops when ctx is done.
+// Cancellation is checked before executing the template and
+// before writing the page to the client, in which case nothing is written,
+// the headers are restored as they were and the returned error wraps ctx.Err().
+// Template execution itself is not preemptible:
+// template funcs that call out to services should observe ctx themselves.
+//
+// The writing of the page to the client is bounded by the deadline of ctx, if any.
+// The write deadline is set using http.ResponseController and cleared afterwards,
+// so a slow client can't hold up the handler beyond the request deadline.
+// When w doesn't support write deadlines, the page is rendered without one.
+func (p *Pages) RenderContext(ctx context.Context, w http.ResponseWriter, dp Provider) error {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		rc := http.NewResponseController(w)
+
+		if err := rc.SetWriteDeadline(deadline); err == nil {
+			defer rc.SetWriteDeadline(time.Time{})
+		} else if !errors.Is(err, http.ErrNotSupported) {
+			p.logError(fmt.Errorf("ehtml RenderContext, set write deadline: %w", err), dp)
+		}
+	}
+
+	set, lang := p.templateSet(dp.Request())
+	name, err := p.render(ctx, cw, set, lang, p.format(dp.Request()), dp)
+	return p.rendered(dp, name, start, cw.n, err)
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// deadlineWriter records the write deadlines set through http.ResponseController.
+type deadlineWriter struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+	// deadlineAtWrite is the deadline in effect during the first Write.
+	deadlineAtWrite time.Time
+}
+
+func (w *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadlines = append(w.deadlines, deadline)
+	return nil
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	if w.deadlineAtWrite.IsZero() && len(w.deadlines) > 0 {
+		w.deadlineAtWrite = w.deadlines[len(w.deadlines)-1]
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestPages_RenderContext(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+
+	withDeadline, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		wantDeadlines []time.Time
+	}{
+		{
+			"No deadline",
+			context.Background(),
+			nil,
+		},
+		{
+			"Deadline",
+			withDeadline,
+			[]time.Time{deadline, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: testTmpl}
+			w := &deadlineWriter{ResponseRecorder: httptest.NewRecorder()}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: http.StatusNotFound,
+			}
+
+			if err := p.RenderContext(tt.ctx, w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); got != "404 template" {
+				t.Errorf("Pages.RenderContext() = %v, want %v", got, "404 template")
+			}
+			if len(w.deadlines) != len(tt.wantDeadlines) {
+				t.Fatalf("Pages.RenderContext() deadlines = %v, want %v", w.deadlines, tt.wantDeadlines)
+			}
+			for i, want := range tt.wantDeadlines {
+				if !w.deadlines[i].Equal(want) {
+					t.Errorf("Pages.RenderContext() deadline %d = %v, want %v", i, w.deadlines[i], want)
+				}
+			}
+			if len(tt.wantDeadlines) > 0 && !w.deadlineAtWrite.Equal(deadline) {
+				t.Errorf("Pages.RenderContext() deadline at write = %v, want %v", w.deadlineAtWrite, deadline)
+			}
+		})
+	}
+}
+
+func TestPages_RenderContext_notSupported(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	p := &Pages{Tmpl: testTmpl}
+	w := httptest.NewRecorder()
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusNotFound,
+	}
+
+	if err := p.RenderContext(ctx, w, d); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "404 template" {
+		t.Errorf("Pages.RenderContext() = %v, want %v", got, "404 template")
+	}
+}
+
+func TestPages_RenderContext_cancel(t *testing.T) {
+	tests := []struct {
+		name   string
+		before bool
+		during bool
+	}{
+		{"Before execute", true, false},
+		{"During execute", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.before {
+				cancel()
+			}
+
+			var executed bool
+			tmpl := template.Must(template.New("error").Funcs(template.FuncMap{
+				"slow": func() string {
+					executed = true
+					if tt.during {
+						cancel()
+					}
+					return "done"
+				},
+			}).Parse("{{ slow }}"))
+
+			p := &Pages{Tmpl: tmpl, CompressMinBytes: 1, StableETag: true}
+			w := httptest.NewRecorder()
+			w.Header().Set("X-Request-Id", "1")
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: http.StatusNotFound,
+			}
+			d.Req.Header.Set("Accept-Encoding", "gzip")
+
+			err := p.RenderContext(ctx, w, d)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Pages.RenderContext() err = %v, want %v", err, context.Canceled)
+			}
+			if executed != tt.during {
+				t.Errorf("Pages.RenderContext() executed = %v, want %v", executed, tt.during)
+			}
+			if w.Code != http.StatusOK || w.Body.Len() != 0 {
+				t.Errorf("Pages.RenderContext() wrote %d %q, want nothing", w.Code, w.Body.String())
+			}
+			if want := (http.Header{"X-Request-Id": {"1"}}); !reflect.DeepEqual(w.Header(), want) {
+				t.Errorf("Pages.RenderContext() headers = %v, want %v", w.Header(), want)
+			}
+		})
+	}
+}
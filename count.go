@@ -0,0 +1,26 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "net/http"
+
+// countWriter counts the bytes written to the client through a ResponseWriter,
+// for RenderN and OnRenderN.
+type countWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (cw *countWriter) Write(b []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Unwrap returns the wrapped ResponseWriter,
+// for http.ResponseController and HeaderWriter.
+func (cw *countWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
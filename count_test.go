@@ -0,0 +1,76 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_RenderN(t *testing.T) {
+	long := strings.Repeat("Lorem ipsum dolor sit amet. ", 100)
+	tmpl := template.Must(template.New("error").Parse("{{ .Message }}"))
+
+	tests := []struct {
+		name    string
+		pages   *Pages
+		method  string
+		accept  string
+		msg     string
+		wantErr error
+	}{
+		{"Plain", &Pages{Tmpl: tmpl}, http.MethodGet, "", "Not found", nil},
+		{"HEAD", &Pages{Tmpl: tmpl}, http.MethodHead, "", "Not found", nil},
+		{"Compressed", &Pages{Tmpl: tmpl}, http.MethodGet, "gzip", long, nil},
+		{
+			"Template error",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Foo }}"))},
+			http.MethodGet,
+			"",
+			"Not found",
+			ErrTemplateExec,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			var hookN int64 = -1
+			tt.pages.OnRenderN = func(dp Provider, n int64, err error) { hookN = n }
+
+			n, err := tt.pages.RenderN(w, &Data{Req: r, Code: http.StatusNotFound, Msg: tt.msg})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pages.RenderN() error = %v, want %v", err, tt.wantErr)
+			}
+			if want := int64(w.Body.Len()); n != want {
+				t.Errorf("Pages.RenderN() = %d, want %d", n, want)
+			}
+			if hookN != n {
+				t.Errorf("Pages.OnRenderN() n = %d, want %d", hookN, n)
+			}
+		})
+	}
+}
+
+func TestPages_RenderProblem_OnRenderN(t *testing.T) {
+	var got int64
+	p := &Pages{OnRenderN: func(dp Provider, n int64, err error) { got = n }}
+	w := httptest.NewRecorder()
+
+	if err := p.RenderProblem(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(w.Body.Len()); got == 0 || got != want {
+		t.Errorf("Pages.OnRenderN() n = %d, want %d", got, want)
+	}
+}
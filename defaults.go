@@ -0,0 +1,173 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"sync"
+)
+
+// TimeoutTmpl is the "timeout" template used by DefaultPages,
+// for the 408 and 504 statuses.
+const TimeoutTmpl = `{{ define "timeout" -}}
+<!DOCTYPE html>
+<html lang="{{ .Lang }}">
+<head>
+	<meta charset="utf-8">
+	<title>{{ .Status.Int }} {{ .StatusText }}: {{ .Message }}</title>
+</head>
+<body>
+	<h1>{{ .Status.Int }} {{ .StatusText }}</h1>
+	<p>This took longer than expected. Please try again in a moment.</p>
+	<p>{{ .Message }}</p>
+</body>
+</html>
+{{- end -}}
+`
+
+// DefaultPages returns Pages with the templates shipped by this package:
+// DefaultTmpl as generic error page and TimeoutTmpl for timeouts.
+// Lang is set to DefaultLang, as the templates use `.Lang`.
+func DefaultPages() *Pages {
+	return &Pages{
+		Tmpl: template.Must(NewDefaultTmpl(nil).Parse(TimeoutTmpl)),
+		Lang: DefaultLang,
+	}
+}
+
+// StyledTmpl holds responsive, Bootstrap-like pages for 400, 401, 403, 404, 500, 502 and 503,
+// timeouts and a generic "error" page in the same style, used by StyledPages.
+// The pages share the "head", "top" and "bottom" partials.
+// They are self-contained, with inline CSS and no external assets, so they work offline.
+// Parse the partials into your own set to reuse the style for other pages.
+const StyledTmpl = `{{ define "head" -}}
+<head>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{ .Status.Int }} {{ .StatusText }}: {{ .Message }}</title>
+	<style{{ with .Nonce }} nonce="{{ . }}"{{ end }}>
+		*, ::before, ::after { box-sizing: border-box; }
+		body {
+			margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center;
+			font-family: system-ui, -apple-system, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+			font-size: 1rem; line-height: 1.5; color: #212529; background-color: #f8f9fa;
+		}
+		.container { width: 100%; max-width: 540px; padding: 1.5rem; text-align: center; }
+		.display { margin: 0; font-size: calc(3.5rem + 3vw); font-weight: 300; line-height: 1.1; color: #6c757d; }
+		h2 { margin: .5rem 0 1rem; font-size: 1.75rem; font-weight: 500; }
+		.lead { font-size: 1.25rem; font-weight: 300; }
+		.message { padding: .75rem 1rem; border: 1px solid #dee2e6; border-radius: .375rem; background-color: #fff; }
+		.btn {
+			display: inline-block; margin-top: 1rem; padding: .375rem .75rem; border-radius: .375rem;
+			color: #fff; background-color: #0d6efd; text-decoration: none;
+		}
+		.btn:hover { background-color: #0b5ed7; }
+		.server .display { color: #dc3545; }
+	</style>
+</head>
+{{- end }}
+
+{{- define "top" -}}
+<!DOCTYPE html>
+<html lang="{{ .Lang }}">
+{{ template "head" . }}
+<body>
+	<main class="container{{ if .Status.IsServerError }} server{{ end }}">
+		<h1 class="display">{{ .Status.Int }}</h1>
+		<h2>{{ .StatusText }}</h2>
+{{- end }}
+
+{{- define "bottom" }}
+		{{ with .Message }}<p class="message">{{ . }}</p>{{ end }}
+		<a class="btn" href="{{ .BasePath }}/">Back to the homepage</a>
+	</main>
+</body>
+</html>
+{{- end }}
+
+{{- define "400" }}{{ template "top" . }}
+		<p class="lead">The server could not understand the request.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "401" }}{{ template "top" . }}
+		<p class="lead">You need to sign in to access this page.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "403" }}{{ template "top" . }}
+		<p class="lead">You don't have permission to access this page.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "404" }}{{ template "top" . }}
+		<p class="lead">The page you are looking for does not exist or has been moved.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "500" }}{{ template "top" . }}
+		<p class="lead">Something went wrong on our end. Please try again later.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "502" }}{{ template "top" . }}
+		<p class="lead">We received an invalid response from an upstream server.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "503" }}{{ template "top" . }}
+		<p class="lead">The service is temporarily unavailable. Please try again in a moment.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "timeout" }}{{ template "top" . }}
+		<p class="lead">This took longer than expected. Please try again in a moment.</p>
+{{- template "bottom" . }}{{ end }}
+
+{{- define "error" }}{{ template "top" . }}
+{{- template "bottom" . }}{{ end }}
+`
+
+// StyledPages returns Pages with StyledTmpl.
+// Lang is set to DefaultLang, as the templates use `.Lang`.
+func StyledPages() *Pages {
+	return &Pages{
+		Tmpl: template.Must(template.New("styled").Parse(StyledTmpl)),
+		Lang: DefaultLang,
+	}
+}
+
+var registeredDefaults = struct {
+	sync.RWMutex
+	m map[Status]*template.Template
+	// src holds the source of the templates, for StableETag.
+	src map[Status]string
+}{
+	m:   make(map[Status]*template.Template),
+	src: make(map[Status]string),
+}
+
+// RegisterDefault registers tmpl as default page for status,
+// allowing libraries to ship their own error pages.
+// Registered defaults are used by all Pages which have no template for the status:
+// templates set by the user, including the generic "error" template, take precedence.
+// A later registration for the same status overrides the earlier one.
+//
+// RegisterDefault is meant to be called from init() and panics if tmpl fails to parse.
+func RegisterDefault(status Status, tmpl string) {
+	t := template.Must(template.New(status.toA()).Parse(tmpl))
+
+	registeredDefaults.Lock()
+	registeredDefaults.m[status] = t
+	registeredDefaults.src[status] = tmpl
+	registeredDefaults.Unlock()
+}
+
+func registeredDefault(s Status) *template.Template {
+	registeredDefaults.RLock()
+	defer registeredDefaults.RUnlock()
+
+	return registeredDefaults.m[s]
+}
+
+func registeredDefaultSource(s Status) string {
+	registeredDefaults.RLock()
+	defer registeredDefaults.RUnlock()
+
+	return registeredDefaults.src[s]
+}
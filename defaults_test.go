@@ -0,0 +1,160 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPages(t *testing.T) {
+	tests := []struct {
+		name   string
+		status Status
+		want   string
+	}{
+		{
+			"Request timeout",
+			http.StatusRequestTimeout,
+			"Please try again",
+		},
+		{
+			"Gateway timeout",
+			http.StatusGatewayTimeout,
+			"Please try again",
+		},
+		{
+			"Generic",
+			http.StatusInternalServerError,
+			"<p>Foo bar</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultPages()
+			d := &Data{Code: tt.status, Msg: "Foo bar"}
+
+			var buf bytes.Buffer
+			if err := p.template(tt.status).Execute(&buf, newPage(d)); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("DefaultPages() = \n%v\nwant containing\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterDefault(t *testing.T) {
+	RegisterDefault(http.StatusTeapot, "First")
+	RegisterDefault(http.StatusTeapot, "Registered {{ .Status }}")
+	t.Cleanup(func() {
+		registeredDefaults.Lock()
+		delete(registeredDefaults.m, http.StatusTeapot)
+		delete(registeredDefaults.src, http.StatusTeapot)
+		registeredDefaults.Unlock()
+	})
+
+	tests := []struct {
+		name   string
+		pages  *Pages
+		status Status
+		want   string
+	}{
+		{
+			"No templates",
+			&Pages{},
+			http.StatusTeapot,
+			"Registered I&#39;m a teapot",
+		},
+		{
+			"Generic user template wins",
+			&Pages{Tmpl: testTmpl},
+			http.StatusTeapot,
+			"Generic template",
+		},
+		{
+			"Other status",
+			&Pages{Tmpl: wrongTmpl},
+			http.StatusNotFound,
+			defaultTmplOut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Code: tt.status, Msg: "Foo bar"}
+
+			var buf bytes.Buffer
+			if err := tt.pages.template(tt.status).Execute(&buf, newPage(d)); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.template() = \n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterDefault_panic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterDefault() did not panic")
+		}
+	}()
+	RegisterDefault(http.StatusTeapot, "{{ .Foo")
+}
+
+func TestStyledPages(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   string
+	}{
+		{http.StatusBadRequest, "could not understand the request"},
+		{http.StatusUnauthorized, "You need to sign in"},
+		{http.StatusForbidden, "You don't have permission"},
+		{http.StatusNotFound, "does not exist or has been moved"},
+		{http.StatusInternalServerError, `<main class="container server">`},
+		{http.StatusBadGateway, "invalid response from an upstream server"},
+		{http.StatusServiceUnavailable, "temporarily unavailable"},
+		{http.StatusGatewayTimeout, "This took longer than expected"},
+		{http.StatusTeapot, "<h2>I&#39;m a teapot</h2>"},
+	}
+
+	p := StyledPages()
+	p.BasePath = "/app"
+	p.RedactFrom = -1
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status.toA(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := p.Render(w, &Data{Code: tt.status, Msg: "Foo bar"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got := w.Body.String()
+			for _, want := range []string{tt.want, "<style>", `<html lang="en">`, `<h1 class="display">` + tt.status.toA()} {
+				if !strings.Contains(got, want) {
+					t.Errorf("StyledPages() = \n%v\nwant containing\n%v", got, want)
+				}
+			}
+			for _, want := range []string{`<p class="message">Foo bar</p>`, `href="/app/"`} {
+				if !strings.Contains(got, want) {
+					t.Errorf("StyledPages() = \n%v\nwant containing\n%v", got, want)
+				}
+			}
+			if strings.Contains(got, "http://") || strings.Contains(got, "https://") {
+				t.Errorf("StyledPages() references external assets:\n%v", got)
+			}
+		})
+	}
+}
@@ -24,7 +24,7 @@ Define some templates:
 		<h1>{{ .Status.Int }} {{ .Status }}</h1>
 		<p>
 			{{ .Message }} while serving {{ .Request.URL.Path }}.
-			Request ID: {{ .ReqID }}
+			Request ID: {{ .Provider.ReqID }}
 		</p>
 		<p><i>This is a generic error page</i><p>
 	</body>
@@ -44,7 +44,7 @@ Define some templates:
 		</p>
 		<p><i>
 			Error: {{ .Message }} while serving {{ .Request.URL.Path }}.
-			Request ID: {{ .ReqID }}
+			Request ID: {{ .Provider.ReqID }}
 		</i></p>
 	</body>
 	</html>
@@ -67,16 +67,21 @@ Define some templates:
 
 Parse them into a globale variable (or part of your Handler object):
 
-	var errorPages = &Pages{template.Must(template.New("error").Parse(templates))}
+	var errorPages = New(WithTemplate(template.Must(template.New("error").Parse(templates))))
+
+Alternatively, keep every page in its own file and load them with ParseFS or ParseDir.
+Files named after a status code ("404.html") or "error.html" define the pages.
+All other files ("head.html") are partials,
+available to all pages by their name without extension:
+
+	{{ template "head" . }}
+
+An underscore prefix ("_footer.html") is stripped from the name.
 
 If you are using Gorilla mux, set the `NotFoundHandler`
 
 	rtr := mux.NewRouter()
-	rtr.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound}); err != nil {
-			log.Println(err)
-		}
-	})
+	rtr.NotFoundHandler = p.HandlerFunc(http.StatusNotFound, "")
 
 Optionally, extend `Data` to add more context.
 As an alternative, you can also roll your own implementation of `Provider`.
@@ -86,9 +91,12 @@ As an alternative, you can also roll your own implementation of `Provider`.
 		ReqID int
 	}
 
+The templates receive a wrapper with additional values, such as `.Lang`.
+Fields of your own type are available through `.Provider`, as in `{{ .Provider.ReqID }}` above.
+
 And whenever something goes wrong in your handlers, call `Render()`:
 
-	err := p.Render(w, &data{Data{req, http.StatusInternalServerError, "DB connection"}, 666})
+	err := p.Render(w, &data{Data{Req: req, Code: http.StatusInternalServerError, Msg: "DB connection"}, 666})
 	if err != nil {
 		log.Println(err)
 	}
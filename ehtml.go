@@ -6,25 +6,101 @@ package ehtml
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"log"
 	"net/http"
+	"runtime/debug"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	texttemplate "text/template"
+	"time"
 )
 
 // Status holds an HTTP status code
 type Status int
 
-// String returns the text descriptiom for the HTTP status code.
+var statusTexts = struct {
+	sync.RWMutex
+	m map[Status]string
+}{m: make(map[Status]string)}
+
+// RegisterStatusText sets the text description for a custom or non-standard status code,
+// such as 499 "Client Closed Request".
+// It takes precedence over http.StatusText, so standard texts can be overridden as well.
+// It is safe for concurrent use, but typically called from init().
+func RegisterStatusText(code Status, text string) {
+	statusTexts.Lock()
+	statusTexts.m[code] = text
+	statusTexts.Unlock()
+}
+
+// String returns the text descriptiom for the HTTP status code,
+// as registered with RegisterStatusText or otherwise known by http.StatusText.
 // It returns the empty string if the code is unknown.
-func (s Status) String() string { return http.StatusText(int(s)) }
+func (s Status) String() string {
+	statusTexts.RLock()
+	text, ok := statusTexts.m[s]
+	statusTexts.RUnlock()
+
+	if ok {
+		return text
+	}
+	return http.StatusText(int(s))
+}
+
+// StringWith returns the text description from texts,
+// or String() when texts has no or an empty entry for the status.
+func (s Status) StringWith(texts map[Status]string) string {
+	if text := texts[s]; text != "" {
+		return text
+	}
+	return s.String()
+}
 
 // Int returns Status as int
 func (s Status) Int() int { return int(s) }
 
+// IsTimeout reports whether the status is 408 Request Timeout or 504 Gateway Timeout.
+func (s Status) IsTimeout() bool {
+	return s == http.StatusRequestTimeout || s == http.StatusGatewayTimeout
+}
+
+// IsRedirect reports whether the status is in the 3xx range (300-399).
+func (s Status) IsRedirect() bool { return s.Class() == 3 }
+
+// IsClientError reports whether the status is in the 4xx range (400-499).
+func (s Status) IsClientError() bool { return s.Class() == 4 }
+
+// IsServerError reports whether the status is in the 5xx range (500-599).
+func (s Status) IsServerError() bool { return s.Class() == 5 }
+
+// Class returns the first digit of the status, like 4 for 404.
+// It returns 0 for statuses outside the 100-999 range.
+func (s Status) Class() int {
+	if s < 100 || s > 999 {
+		return 0
+	}
+	return s.Int() / 100
+}
+
 func (s Status) toA() string { return strconv.Itoa(s.Int()) }
 
+// classTmpl returns the name of the class template for s, like "4xx".
+// It returns the empty string for statuses outside the 100-999 range.
+func (s Status) classTmpl() string {
+	class := s.Class()
+	if class == 0 {
+		return ""
+	}
+	return strconv.Itoa(class) + "xx"
+}
+
 // Provider of data to templates
 type Provider interface {
 	// Request returns the incomming http Request object
@@ -36,11 +112,118 @@ type Provider interface {
 	String() string
 }
 
+// wrapper is implemented by the Providers Render wraps around the original,
+// such as for redaction.
+type wrapper interface {
+	unwrap() Provider
+}
+
+// optional returns dp as the optional interface T,
+// looking through the wrappers added by Render.
+func optional[T any](dp Provider) (T, bool) {
+	for {
+		if t, ok := dp.(T); ok {
+			return t, true
+		}
+
+		w, ok := dp.(wrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		dp = w.unwrap()
+	}
+}
+
+// HeaderProvider can optionally be implemented by a Provider,
+// to send additional headers with the page. For example, Retry-After or Allow.
+// The headers are set before writing the status, replacing existing values,
+// also when RenderError is sent.
+type HeaderProvider interface {
+	Headers() http.Header
+}
+
+// Unwrapper can optionally be implemented by a Provider,
+// to carry the error which caused the page.
+// Render logs the error to Pages.ErrorLog.
+// It is only shown to the client by templates using `.ErrText`,
+// unless redacted.
+type Unwrapper interface {
+	Unwrap() error
+}
+
+// errorOf returns the error carried by dp, if any.
+func errorOf(dp Provider) error {
+	if u, ok := optional[Unwrapper](dp); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// errorText returns the message of the error carried by dp,
+// or the empty string if there is none.
+// Wrappers implementing ErrText() take precedence, so redaction applies.
+func errorText(dp Provider) string {
+	if e, ok := dp.(interface{ ErrText() string }); ok {
+		return e.ErrText()
+	}
+	if err := errorOf(dp); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// HTTPStatuser can optionally be implemented by a Provider,
+// to write another status to the client than Status(), which selects the template.
+// For example, a soft error styled with the "500" template but sent as 200 OK.
+type HTTPStatuser interface {
+	HTTPStatus() Status
+}
+
+// httpStatus returns the status to write for dp:
+// a non-zero StatusOverride from MetaProvider or HTTPStatus() from HTTPStatuser,
+// Status() otherwise.
+func httpStatus(dp Provider) Status {
+	if m := metaOf(dp); m != nil && m.StatusOverride != 0 {
+		return m.StatusOverride
+	}
+	if hs, ok := optional[HTTPStatuser](dp); ok {
+		if s := hs.HTTPStatus(); s != 0 {
+			return s
+		}
+	}
+	return dp.Status()
+}
+
 // Data can be used as a default or embedded type to implement Provider.
 type Data struct {
 	Req  *http.Request
 	Code Status
 	Msg  string
+
+	// ID optionally holds the request ID, see ReqID.
+	ID string
+
+	// Src optionally identifies the handler which produced the error,
+	// for diagnostics on the page or in logs.
+	Src string
+
+	// Stack optionally holds a stack trace, for display in development.
+	// It is only shown by templates in DevMode, see StackProvider.
+	Stack string
+
+	// Hdr optionally holds headers to send with the page.
+	Hdr http.Header
+
+	// Err optionally holds the error which caused the page.
+	// It is logged by Render and only shown by templates using `.ErrText`.
+	Err error
+
+	// Fields optionally holds custom values for the templates,
+	// as `{{ .Fields.SupportEmail }}` or `{{ .Field "SupportEmail" }}`.
+	// Use the latter when the Provider can be wrapped by Render, such as for redaction.
+	// See FieldProvider.
+	Fields map[string]interface{}
 }
 
 // Request implements Provider
@@ -52,56 +235,770 @@ func (d *Data) Status() Status { return d.Code }
 // Message implements Provider
 func (d *Data) Message() string { return d.Msg }
 
+// Headers implements HeaderProvider
+func (d *Data) Headers() http.Header { return d.Hdr }
+
+// Source returns the handler which produced the error, if set.
+// It is not part of String().
+func (d *Data) Source() string { return d.Src }
+
+// IsTLS reports whether the request was received over TLS.
+// It returns false if there is no request.
+func (d *Data) IsTLS() bool { return d.Req != nil && d.Req.TLS != nil }
+
+// Proto returns the protocol version of the request, such as "HTTP/2.0".
+// It returns the empty string if there is no request.
+// It is not part of String().
+func (d *Data) Proto() string {
+	if d.Req == nil {
+		return ""
+	}
+	return d.Req.Proto
+}
+
+// RemoteAddr returns the network address of the client, as set by the server.
+// It returns the empty string if there is no request.
+// It is not part of String().
+func (d *Data) RemoteAddr() string {
+	if d.Req == nil {
+		return ""
+	}
+	return d.Req.RemoteAddr
+}
+
+// ReqID returns ID, or the request ID from the context of the request
+// as registered with RegisterRequestIDKey.
+// It allows for `{{ .ReqID }}` in templates and is not part of String().
+func (d *Data) ReqID() string {
+	if d.ID != "" || d.Req == nil {
+		return d.ID
+	}
+	return requestID(d.Req.Context())
+}
+
+// NewData returns Data for r, with the request ID from its context set as ID.
+// r may be nil.
+func NewData(r *http.Request, code Status, msg string) *Data {
+	d := &Data{Req: r, Code: code, Msg: msg}
+	if r != nil {
+		d.ID = requestID(r.Context())
+	}
+	return d
+}
+
+// WithStatus sets Code and returns d, for chaining. For example:
+//
+//	ehtml.NewData(r, 0, "").WithStatus(http.StatusNotFound).WithMessage("No such user")
+func (d *Data) WithStatus(code Status) *Data {
+	d.Code = code
+	return d
+}
+
+// WithMessage sets Msg and returns d, for chaining.
+func (d *Data) WithMessage(msg string) *Data {
+	d.Msg = msg
+	return d
+}
+
+// WithRequest sets Req and returns d, for chaining.
+func (d *Data) WithRequest(r *http.Request) *Data {
+	d.Req = r
+	return d
+}
+
+var requestIDKey = struct {
+	sync.RWMutex
+	key interface{}
+}{}
+
+// RegisterRequestIDKey sets the context key under which request IDs are stored,
+// typically by a request ID middleware, for NewData and Data.ReqID.
+// Values are formatted with fmt.Sprint.
+// It is safe for concurrent use, but typically called from init().
+func RegisterRequestIDKey(key interface{}) {
+	requestIDKey.Lock()
+	requestIDKey.key = key
+	requestIDKey.Unlock()
+}
+
+// requestID returns the request ID from ctx, or the empty string if there is none.
+func requestID(ctx context.Context) string {
+	requestIDKey.RLock()
+	key := requestIDKey.key
+	requestIDKey.RUnlock()
+
+	if key == nil {
+		return ""
+	}
+	if v := ctx.Value(key); v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Unwrap implements Unwrapper, returning Err for errors.Is and errors.As.
+func (d *Data) Unwrap() error { return d.Err }
+
+// ErrText returns the message of Err, or the empty string if Err is nil.
+// It allows for `{{ .ErrText }}` in templates and is not part of String().
+// Data is not an error itself, use Unwrap to get Err.
+func (d *Data) ErrText() string {
+	if d.Err == nil {
+		return ""
+	}
+	return d.Err.Error()
+}
+
 func (d *Data) String() string {
 	return fmt.Sprintf("%d %s: %s", d.Code, d.Code, d.Msg)
 }
 
-// DefaultTmpl is a placeholder template for `Pages.Render()`
+// Lang returns DefaultLang, for `.Lang` in DefaultTmpl when it is executed
+// with the Data itself. Render exposes the language of the Pages instead.
+func (d *Data) Lang() string { return DefaultLang }
+
+// StatusText returns the text of the status, for `.StatusText` in DefaultTmpl
+// when it is executed with the Data itself. See Status.String().
+func (d *Data) StatusText() string { return d.Code.String() }
+
+// page is the data of the templates, wrapping the Provider.
+// The Provider as passed to Render, after Transform, is available to templates as `.Provider`,
+// while the status and message are those shown, after DefaultStatus, redaction and truncation.
+type page struct {
+	Provider
+	// shown is the Provider with the wrappers of transform applied.
+	shown Provider
+
+	RateLimit *rateLimit
+	BlockedBy *blockedBy
+	BasePath  string
+	Lang      string
+	Nonce     string
+
+	localize func(lang string, s Status) string
+	texts    map[Status]string
+	stack    string
+
+	// Content is the output of the page, for the Layout.
+	Content template.HTML
+}
+
+// Status returns the status shown, see Pages.DefaultStatus.
+func (pg *page) Status() Status { return pg.shown.Status() }
+
+// Message returns the message shown, which may be redacted, truncated or a default message.
+func (pg *page) Message() string { return pg.shown.Message() }
+
+func (pg *page) String() string { return pg.shown.String() }
+
+// ErrText returns the message of the error carried by the Provider, for `{{ .ErrText }}`.
+// It is empty if there is none, or when redacted.
+func (pg *page) ErrText() string { return errorText(pg.shown) }
+
+// ReqID returns the request ID of the Provider, see Data.ReqID.
+// It is empty if the Provider has none.
+func (pg *page) ReqID() string {
+	if v, ok := optional[interface{ ReqID() string }](pg.Provider); ok {
+		return v.ReqID()
+	}
+	return ""
+}
+
+// Source returns the source of the Provider, see Data.Source.
+func (pg *page) Source() string {
+	if v, ok := optional[interface{ Source() string }](pg.Provider); ok {
+		return v.Source()
+	}
+	return ""
+}
+
+// Proto returns the protocol of the request, see Data.Proto.
+func (pg *page) Proto() string {
+	if v, ok := optional[interface{ Proto() string }](pg.Provider); ok {
+		return v.Proto()
+	}
+	return ""
+}
+
+// RemoteAddr returns the address of the client, see Data.RemoteAddr.
+func (pg *page) RemoteAddr() string {
+	if v, ok := optional[interface{ RemoteAddr() string }](pg.Provider); ok {
+		return v.RemoteAddr()
+	}
+	return ""
+}
+
+// IsTLS reports whether the request was received over TLS, see Data.IsTLS.
+func (pg *page) IsTLS() bool {
+	if v, ok := optional[interface{ IsTLS() bool }](pg.Provider); ok {
+		return v.IsTLS()
+	}
+	return false
+}
+
+// StatusText returns the text of the status,
+// localized for the page's language by Pages.LocalizeStatus, if set,
+// or else from Pages.StatusText.
+func (pg *page) StatusText() string {
+	s := pg.Status()
+	if pg.localize != nil {
+		if text := pg.localize(pg.Lang, s); text != "" {
+			return text
+		}
+	}
+	return s.StringWith(pg.texts)
+}
+
+// DefaultTmpl is a placeholder template for `Pages.Render()`.
+// It uses `.Lang` and `.StatusText`, which Render always provides for this template
+// and Data implements, for sets parsed from DefaultTmpl.
 const DefaultTmpl = `{{ define "error" -}}
 <!DOCTYPE html>
-<html lang="en">
+<html lang="{{ .Lang }}">
 <head>
 	<meta charset="utf-8">
-	<title>{{ .String }}</title>
+	<title>{{ .Status.Int }} {{ .StatusText }}: {{ .Message }}</title>
 </head>
 <body>
-	<h1>{{ .Status.Int }} {{ .Status }}</h1>
+	<h1>{{ .Status.Int }} {{ .StatusText }}</h1>
 	<p>{{ .Message }}</p>
 </body>
 </html>
 {{- end -}}
 `
 
-var defTmpl = template.Must(template.New("error").Parse(DefaultTmpl))
+var defTmpl = NewDefaultTmpl(nil)
+
+// NewDefaultTmpl returns a new set holding DefaultTmpl as "error" template,
+// with DefaultFuncs and funcs added before parsing.
+// Pages for specific statuses using funcs can be parsed into the returned set,
+// so they don't have to build a set from scratch.
+// Executed outside of Render, Providers not embedding Data need a Lang method for `.Lang` in DefaultTmpl.
+func NewDefaultTmpl(funcs template.FuncMap) *template.Template {
+	return template.Must(template.New("error").Funcs(DefaultFuncs()).Funcs(funcs).Parse(DefaultTmpl))
+}
+
+// DefaultFuncs returns the template funcs of NewDefaultTmpl. They are:
+//
+//   - statusText returns the text of a status code, like Status.String().
+//     Eg: `{{ statusText 404 }}` for "Not Found".
+//
+// Add them to other sets with Pages.Funcs, before parsing.
+func DefaultFuncs() template.FuncMap {
+	return template.FuncMap{
+		"statusText": statusText,
+	}
+}
+
+func statusText(code int) string { return Status(code).String() }
+
+// DefaultCharset is used when Pages.Charset is empty.
+const DefaultCharset = "utf-8"
+
+// DefaultLang is the language of DefaultTmpl, when Pages.Lang is not set.
+const DefaultLang = "en"
+
+// DefaultTmplName is reported as template name when DefaultTmpl is used.
+const DefaultTmplName = "default"
+
+func tmplName(tmpl *template.Template) string {
+	if tmpl == defTmpl {
+		return DefaultTmplName
+	}
+	return tmpl.Name()
+}
+
+// Encoder transcodes a rendered page from UTF-8 into another character encoding.
+type Encoder interface {
+	// Charset returns the name of the encoding, as used in the Content-Type header.
+	Charset() string
+	// Encode transcodes the UTF-8 encoded page.
+	Encode(page []byte) ([]byte, error)
+}
 
 // Pages allows setting of status page templates.
 // Whenever such page needs to be served, a Lookup is done for a template
-// named by the code. Eg: "404".
+// named by the Provider, if it is a TemplateNamer, and then by the code. Eg: "404".
+// For timeout statuses (408 and 504), a template named "timeout" is tried next.
+// Then the template for the class of the status is tried. Eg: "4xx" or "5xx".
 // A generic template named "error" can be provided
 // and will be used if there is no status-specific template defined.
 //
 // If Tmpl is `nil` or no templates are found using above Lookup scheme,
-// `DefaultErrTmpl` will be used.
+// a default registered for the status with RegisterDefault is used.
+// Failing that, `DefaultTmpl` will be used.
+//
+// Templates always receive a wrapper around the Provider,
+// which exposes additional values such as `.BasePath` and `.Lang`.
+// It forwards the methods of Provider and Data, such as `.ReqID` and `.Fields`,
+// but fields of a custom Provider type need to be accessed through `.Provider`.
+// For example: `{{ .Provider.UserName }}`.
+// `.Provider` is the Provider as passed to Render, after Transform:
+// use `.Message`, which may be redacted or truncated, to show the message.
 type Pages struct {
+	// Tmpl is the template set of the pages.
+	// It may not be modified while pages are rendered,
+	// use SetTemplate to replace the set at runtime.
 	Tmpl *template.Template
+	// swapped holds the set from SetTemplate, used instead of Tmpl.
+	swapped atomic.Pointer[template.Template]
+	// watch holds the templates loaded by WatchFS, used instead of Tmpl.
+	watch *watcher
+	// lookups caches the templates found per status in Tmpl, the SetTemplate set and Locales.
+	lookups lookupCache
+	// funcs added with Funcs or ParseFSFuncs, for WatchFS.
+	funcs template.FuncMap
+
+	// Locales holds translated template sets, keyed by language tag. Eg: "nl" or "de-CH".
+	// Render uses the set best matching the Accept-Language request header.
+	// Regional variants fall back to their base language ("fr-CA" to "fr"),
+	// and Tmpl is used when no locale matches.
+	// Accept-Language is added to the Vary header, so shared caches keep the languages apart.
+	// The language tags may not be changed after the first page is rendered.
+	Locales map[string]*template.Template
+	// localeMatcher matches Accept-Language to Locales.
+	localeMatcher localeMatcher
+
+	// Layout optionally names a template which wraps the pages of a set,
+	// such as a base layout with the common head and navigation. Eg: "layout".
+	// The page for the status is looked up as usual and executed first.
+	// Its output is available to the layout as `{{ .Content }}`,
+	// which is executed with the same data as the page otherwise.
+	// Sets without the layout template, the built-in DefaultTmpl
+	// and registered defaults render the page as is.
+	Layout string
+
+	// DisableNegotiation makes Render always produce html.
+	// Otherwise, the format is negotiated from the Accept header of the request:
+	// "html", "json" or "txt". Html is used when Accept is empty or prefers nothing else.
+	// The Content-Type header is set accordingly, and Accept is added to the Vary header,
+	// so shared caches keep the formats apart.
+	//
+	// Render first looks up the templates of the lookup scheme, with the format as extension.
+	// Eg: "404.json", "4xx.json", then "error.json".
+	// For html, these names are looked up in Tmpl or Locales, followed by the
+	// lookup scheme as described above.
+	// For json and txt, these names are looked up in TextTmpl,
+	// followed by a built-in default: the JSON object
+	// `{"status":404,"message":"...","error":"404 Not Found"}` or the String() of the Provider.
+	// Encoders and Sanitizer only apply to html.
+	DisableNegotiation bool
+
+	// TextTmpl holds the json and txt templates for negotiated formats and RenderText.
+	// They are executed with text/template, so their output is not HTML escaped.
+	TextTmpl *texttemplate.Template
+
+	// StableETag sets a weak ETag header, derived from a hash of the template sources,
+	// the selected template, the status, String() and language of the page
+	// and the options which change the output, ignoring other request data.
+	// The Sanitizer is identified by its func name, so closures should not depend on changing state.
+	// Identical pages therefore get identical ETags, also between restarts,
+	// so clients can keep their cached copies across deploys.
+	// GET and HEAD requests with a matching If-None-Match header are served
+	// "304 Not Modified" without a body.
+	//
+	// The sources are hashed before a template set is first executed by Pages,
+	// so sets should not be executed elsewhere before the first Render.
+	StableETag bool
+
+	// EnableETag sets a weak ETag header, derived from a hash of the rendered page.
+	// GET and HEAD requests with a matching If-None-Match header are served
+	// "304 Not Modified" without a body.
+	// Unlike StableETag, the page is always rendered, but any change in the output,
+	// including request dependent values, results in a new ETag.
+	// StableETag takes precedence when both are set.
+	EnableETag bool
+	// fingerprints of the template sets, for StableETag.
+	fingerprints fingerprints
+
+	// Charset of html pages, as set in the Content-Type header.
+	// The page is not transcoded, use Encoders for that.
+	// DefaultCharset is used when empty.
+	Charset string
+
+	// LocalizeStatus, when set, returns the status text in the language lang,
+	// as exposed to the templates as `.StatusText`. Eg: "Niet gevonden" for 404 in "nl".
+	// lang is the language tag of the template set from Locales, or Lang.
+	// Status.String() is used when it returns an empty string.
+	LocalizeStatus func(lang string, s Status) string
+
+	// StatusText overrides the status texts exposed to the templates as `.StatusText`,
+	// for the Pages only. Eg: 529 "Site Overloaded", which http.StatusText does not know.
+	// It also provides the fallback message of DefaultMessages.
+	// Use RegisterStatusText to set texts for `.Status` and Status.String() as well.
+	StatusText map[Status]string
+
+	// DisableCompression disables gzip compression of rendered pages.
+	// By default, pages of at least CompressMinBytes are compressed
+	// when the Accept-Encoding header of the request allows gzip.
+	DisableCompression bool
+
+	// CompressMinBytes is the minimal size of a page to be compressed.
+	// DefaultCompressMinBytes is used when 0.
+	CompressMinBytes int
+
+	// Encoders transcode the rendered page per status,
+	// for clients that can't handle UTF-8.
+	// The charset of the Content-Type header is set accordingly.
+	// Statuses without Encoder are served as UTF-8.
+	// See the ehtmlcharset package for Encoders based on golang.org/x/text/encoding.
+	Encoders map[Status]Encoder
+
+	// FallbackTmpl, when set, is sent with status 500 instead of RenderError,
+	// when rendering a page fails.
+	// It is executed with the same data as the pages, see Pages.
+	// RenderError is still sent if FallbackTmpl fails as well.
+	FallbackTmpl *template.Template
+
+	// Sanitizer is applied to each rendered page before it is encoded and sent,
+	// as an extra safety layer when messages might contain user content.
+	// For example, a HTML sanitizer like bluemonday:
+	//
+	//	Sanitizer: bluemonday.UGCPolicy().SanitizeBytes
+	//
+	// Sanitizer is not applied to RenderError.
+	Sanitizer func(page []byte) []byte
+
+	// TreatEmptyAsError makes Render use DefaultTmpl when a template
+	// rendered to empty output, which usually means it is misconfigured.
+	// For example, a status template containing only `{{ define }}` blocks.
+	TreatEmptyAsError bool
+
+	// MaxBufferBytes limits the size of a rendered page.
+	// Template execution is aborted when the limit is exceeded
+	// and RenderError is sent instead,
+	// so a runaway template can't exhaust the memory.
+	// Unlimited when 0.
+	MaxBufferBytes int
+
+	// Lang is the language of the pages, exposed to the templates as `.Lang`
+	// and used for the lang attribute of DefaultTmpl.
+	// When a template set from Locales is used, `.Lang` is its language tag instead.
+	// DefaultLang is used when empty.
+	Lang string
+
+	// BasePath is exposed to the templates as `.BasePath`,
+	// for links to assets when the application is mounted under a sub-path.
+	// For example: `{{ .BasePath }}/static/error.css`.
+	BasePath string
+
+	// Redirects maps statuses to an URL.
+	// Instead of rendering a page, Render redirects the client there with 303 See Other.
+	// Requires a Provider with a Request.
+	Redirects map[Status]string
+
+	// FlashCookie, when set, is the name of a cookie in which Render stores the message
+	// before redirecting, so the target page can show it.
+	// See Flash for retrieving the message. Off by default.
+	FlashCookie string
+
+	// FlashMaxAge of the flash cookie in seconds.
+	// DefaultFlashMaxAge is used when 0.
+	FlashMaxAge int
+
+	// DefaultStatus is used when a Provider returns status 0, for example when
+	// the Code of Data was not set, both for the template lookup and the response.
+	// 500 Internal Server Error is used when 0.
+	DefaultStatus Status
+
+	// DefaultMessages, when set, are substituted for empty messages of Providers,
+	// so pages don't show an empty message. Eg: "The page you requested does not exist" for 404.
+	// Statuses without an entry get their status text, like "Not Found", see StatusText.
+	DefaultMessages map[Status]string
+
+	// Transform, when set, is called at the start of each Render method,
+	// to enrich or replace the Provider. For example, to add a request ID or the user.
+	// It may return dp itself or a new Provider wrapping it.
+	// When it returns nil, dp is used unchanged.
+	Transform func(r *http.Request, dp Provider) Provider
+
+	// StatusMapper, when set, returns the status to render for an error passed to
+	// RenderErr or Handle without status. Eg: 404 for errors.Is(err, sql.ErrNoRows).
+	// When it returns 0, StatusForError is used.
+	StatusMapper func(err error) Status
+
+	// RedactFrom is the lowest status for which the message is not shown to the client,
+	// so internal details don't leak from server errors.
+	// RedactedMessage is shown instead and the real message is logged to ErrorLog.
+	// DefaultRedactFrom (500) is used when 0, negative disables redaction.
+	// Messages are never redacted in DevMode.
+	RedactFrom Status
+
+	// RedactedMessage replaces redacted messages.
+	// DefaultRedactedMessage is used when empty.
+	RedactedMessage string
+
+	// MaxMessageLen truncates messages longer than the number of characters (runes),
+	// with an ellipsis appended, before they reach the templates.
+	// Messages often contain user input, such as paths or form values,
+	// which should not be echoed back in full. Logs still get the full message.
+	// Unlimited when 0.
+	MaxMessageLen int
+
+	// DevMode enables diagnostics which should not be exposed in production,
+	// such as capturing stack traces in RenderRecovered and Handle,
+	// showing them as `{{ .Stack }}`, see StackProvider,
+	// and showing the messages of server errors, see RedactFrom.
+	DevMode bool
+
+	// OnPanic, when set, is called by Recover with the recovered value
+	// and the stack trace of the panic, for logging.
+	OnPanic func(r *http.Request, rec interface{}, stack []byte)
+
+	// ErrorLog, when set, logs the errors returned by the Render methods,
+	// together with the Provider as returned by LogString.
+	ErrorLog *log.Logger
+
+	// Observer, when set, receives the status, template name and duration of each
+	// page served by the same methods as OnRender, before OnRender is called.
+	Observer Observer
+
+	// OnRender, when set, is called once for every page served by
+	// Render, RenderContext, RenderUsing, RenderText, RenderMultipart and RenderProblem,
+	// after the response is written.
+	// dp is the Provider as rendered, after Transform and redaction.
+	// err is the error returned, such as a failure to execute the template,
+	// in which case RenderError or FallbackTmpl was sent.
+	// Use it for centralized logging or metrics of served pages.
+	OnRender func(dp Provider, err error)
+
+	// OnRenderN, when set, is called like OnRender, after it,
+	// with n the number of bytes of the body written to the client, as returned by RenderN.
+	// Use it for accounting of the sent bytes.
+	OnRenderN func(dp Provider, n int64, err error)
+
+	// OnFallback, when set, is called when an html page is rendered with the generic
+	// "error" template or the built-in DefaultTmpl, as there is no template for the status
+	// by its code, "timeout" or class. Eg: to count missing templates in a metric.
+	// It is also called when TreatEmptyAsError falls back to DefaultTmpl.
+	OnFallback func(s Status)
+
+	// Log5xxBody logs the body of rendered server error pages (5xx) to ErrorLog,
+	// exactly as sent to the client, for postmortems.
+	// Other statuses are not logged.
+	Log5xxBody bool
+
+	// MaxHeaderBytes limits the total size of the optional headers set by Render,
+	// such as the rate limit headers,
+	// so the response doesn't get rejected by servers or proxies.
+	// Headers exceeding the limit are dropped and logged to ErrorLog.
+	// Essential headers, like Content-Type, are always set.
+	// DefaultMaxHeaderBytes is used when 0, negative disables the limit.
+	MaxHeaderBytes int
+
+	// EnableMultipart opts in to "multipart/mixed" responses from RenderMultipart.
+	EnableMultipart bool
+
+	// DeferHeader skips the explicit WriteHeader call for soft errors rendered as 200 OK,
+	// letting the ResponseWriter write the header implicitly on the first Write.
+	// Some ResponseWriter wrappers behave better that way.
+	// Headers, including Content-Length, are still set before the first Write
+	// and are sent along with the implicit header.
+	// Other statuses are always written explicitly.
+	DeferHeader bool
+
+	// StripHeaders are deleted from the ResponseWriter's headers before rendering.
+	// Use it to clean up headers set by earlier handlers or middleware,
+	// such as caching headers, which don't belong on an error page.
+	StripHeaders []string
+
+	// CacheControl sets the Cache-Control header per status. Eg: "no-store" for 404,
+	// so clients don't keep showing a page after the route is fixed, and "max-age=30" for 503.
+	// Statuses without an entry keep the Cache-Control header as is, see StripHeaders.
+	// A Cache-Control header from a HeaderProvider takes precedence.
+	//
+	// The header is also sent on "304 Not Modified" responses from StableETag or EnableETag.
+	// Note that "no-store" prevents clients from caching the page, and so from revalidating it
+	// with If-None-Match. Use "no-cache" to have clients revalidate with the ETag on every request.
+	CacheControl map[Status]string
+
+	// CSP is the Content-Security-Policy set on rendered pages.
+	// For example: "default-src 'none'; style-src 'self'".
+	// No policy is set when empty.
+	//
+	// Each occurrence of CSPNonce is replaced by a random nonce, generated for every response
+	// and exposed to the templates as `.Nonce`. For example, with
+	// "default-src 'none'; style-src 'nonce-{nonce}'" inline styles are allowed as
+	// `<style nonce="{{ .Nonce }}">`. StyledTmpl does so, when a nonce is set.
+	// StableETag is disabled when CSP uses a nonce, as cached pages would carry a stale one.
+	CSP string
+
+	// CSPReportOnly sends CSP as Content-Security-Policy-Report-Only header instead,
+	// so violations are reported but not enforced, for a safe rollout of the policy.
+	CSPReportOnly bool
+
+	// CSPReportURI and CSPReportTo are appended to CSP as
+	// the report-uri and report-to directives, when set.
+	CSPReportURI string
+	CSPReportTo  string
+
+	// DisableNoSniff omits the "X-Content-Type-Options: nosniff" header,
+	// which is otherwise set on every rendered response.
+	// It prevents browsers from interpreting the response as another content type,
+	// for example the plain text RenderError as HTML.
+	// Only disable it if the header is managed elsewhere, such as a proxy.
+	DisableNoSniff bool
+
+	// SecurityHeaders sets "X-Frame-Options: DENY" and "Referrer-Policy: no-referrer"
+	// on every rendered response, so error pages can't be framed for clickjacking
+	// and don't leak their URL, which may contain reflected input, to linked sites.
+	// Headers from a HeaderProvider take precedence.
+	SecurityHeaders bool
+
+	// WWWAuthenticate sets the WWW-Authenticate header on "401 Unauthorized" responses,
+	// which browsers expect to start their authentication flow. Eg: `Basic realm="admin"`.
+	// A WWW-Authenticate header already set, such as by an authentication middleware,
+	// is kept and one from a HeaderProvider takes precedence.
+	WWWAuthenticate string
 }
 
 func (p *Pages) template(s Status) *template.Template {
-	if p.Tmpl == nil {
-		return defTmpl
+	return p.lookupHTML(p.base(), s)
+}
+
+// TemplateFor returns the name of the html template Render selects for s, and the template.
+// The name is that of the exact match (eg: "404"), "timeout", the class (eg: "4xx"), "error",
+// or DefaultTmplName when the built-in DefaultTmpl is used.
+// With negotiation enabled, "<code>.html" and "error.html" take precedence.
+// Locales are not considered, as they depend on the request.
+func (p *Pages) TemplateFor(s Status) (name string, tmpl *template.Template) {
+	tmpl = p.template(s)
+	return tmplName(tmpl), tmpl
+}
+
+// TemplateNamer can optionally be implemented by a Provider,
+// to select a template by name regardless of the status.
+// For example, a "maintenance" page for several statuses.
+// When the set has no template by that name, or it is empty,
+// the template is looked up by status as usual.
+// It applies to html pages only.
+type TemplateNamer interface {
+	TemplateName() string
+}
+
+// lookupPage returns the html template for dp in set:
+// the one named by dp if it is a TemplateNamer, or otherwise the one for its status.
+func (p *Pages) lookupPage(set *template.Template, dp Provider) *template.Template {
+	if tn, ok := optional[TemplateNamer](dp); ok && set != nil {
+		if name := tn.TemplateName(); name != "" {
+			if tmpl := set.Lookup(name); tmpl != nil {
+				return tmpl
+			}
+		}
 	}
+	return p.lookupHTML(set, dp.Status())
+}
 
-	if tmpl := p.Tmpl.Lookup(s.toA()); tmpl != nil {
+// lookupHTML returns the html template for s in set,
+// preferring the "html" format templates unless DisableNegotiation is set.
+func (p *Pages) lookupHTML(set *template.Template, s Status) *template.Template {
+	if tmpl := p.lookupSetHTML(set, s); tmpl != nil {
 		return tmpl
 	}
 
-	if tmpl := p.Tmpl.Lookup("error"); tmpl != nil {
+	if tmpl := registeredDefault(s); tmpl != nil {
 		return tmpl
 	}
 
 	return defTmpl
 }
 
+// lookupSetHTML returns the html template for s defined in set, or nil.
+// Lookups in the sets of p are cached, see cacheable.
+func (p *Pages) lookupSetHTML(set *template.Template, s Status) *template.Template {
+	cache := p.cacheable(set)
+	if cache {
+		if tmpl, ok := p.lookups.load(set, s); ok {
+			return tmpl
+		}
+	}
+
+	var tmpl *template.Template
+	if !p.DisableNegotiation {
+		tmpl = lookupFormat(set, s, formatHTML)
+	}
+	if tmpl == nil {
+		tmpl = lookupSet(set, s)
+	}
+
+	if cache {
+		p.lookups.store(set, s, tmpl)
+	}
+	return tmpl
+}
+
+// base returns the set loaded by WatchFS or set by SetTemplate, if any, or Tmpl.
+func (p *Pages) base() *template.Template {
+	if p.watch != nil {
+		return p.watch.set(p)
+	}
+	if tmpl := p.swapped.Load(); tmpl != nil {
+		return tmpl
+	}
+	return p.Tmpl
+}
+
+// SetTemplate atomically replaces the template set used instead of Tmpl.
+// It is safe to call while pages are rendered,
+// which use either the previous or the new set in their entirety.
+// Tmpl is not modified and is ignored once SetTemplate was called.
+// Passing nil reverts to Tmpl.
+func (p *Pages) SetTemplate(tmpl *template.Template) {
+	p.swapped.Store(tmpl)
+	p.lookups.reset()
+}
+
+func lookupSet(set *template.Template, s Status) *template.Template {
+	return lookupNames(set, pageNames(s, ""))
+}
+
+// lookupFormat looks up the template for s in set, with the format as extension.
+// Eg: "404.json" or "error.json".
+func lookupFormat[T lookuper[T]](set T, s Status, format string) T {
+	return lookupNames(set, pageNames(s, "."+format))
+}
+
+// pageNames returns the names of the templates for s in order of precedence,
+// with suffix appended: the code, "timeout" for timeouts, the class and "error".
+func pageNames(s Status, suffix string) []string {
+	names := make([]string, 0, 4)
+	names = append(names, s.toA()+suffix)
+	if s.IsTimeout() {
+		names = append(names, "timeout"+suffix)
+	}
+	if class := s.classTmpl(); class != "" {
+		names = append(names, class+suffix)
+	}
+	return append(names, "error"+suffix)
+}
+
+// lookuper is a template set of html/template or text/template.
+type lookuper[T any] interface {
+	comparable
+	Lookup(name string) T
+}
+
+// lookupNames returns the first template of names defined in set,
+// or the zero value if there is none.
+func lookupNames[T lookuper[T]](set T, names []string) T {
+	var none T
+	if set == none {
+		return none
+	}
+	for _, name := range names {
+		if tmpl := set.Lookup(name); tmpl != none {
+			return tmpl
+		}
+	}
+	return none
+}
+
+// MaxPooledBufferBytes is the largest capacity of a buffer kept for reuse after rendering.
+// Larger buffers, grown by an exceptionally big page, are left to the garbage collector,
+// so they don't stay allocated for the lifetime of the process.
+// There is no limit when 0 or less. It should only be set before rendering, eg: in init().
+var MaxPooledBufferBytes = 64 << 10
+
 type bufPool struct {
 	p sync.Pool
 }
@@ -114,34 +1011,663 @@ func (p *bufPool) Get() *bytes.Buffer {
 	return new(bytes.Buffer)
 }
 
+// Put b back in the pool, unless it is larger than MaxPooledBufferBytes.
 func (p *bufPool) Put(b *bytes.Buffer) {
+	if MaxPooledBufferBytes > 0 && b.Cap() > MaxPooledBufferBytes {
+		return
+	}
 	b.Reset()
 	p.p.Put(b)
 }
 
 var buffers = &bufPool{}
 
-// RenderError is returned to the client if the template failed to render.
+// ErrMaxBufferBytes is returned when a rendered page exceeds Pages.MaxBufferBytes.
+var ErrMaxBufferBytes = errors.New("ehtml: rendered page exceeds MaxBufferBytes")
+
+// limitWriter writes to buf, up to max bytes in total.
+type limitWriter struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (l *limitWriter) Write(b []byte) (int, error) {
+	if l.buf.Len()+len(b) > l.max {
+		return 0, ErrMaxBufferBytes
+	}
+	return l.buf.Write(b)
+}
+
+// RenderError is returned to the client if the template failed to render
+// and there is no Pages.FallbackTmpl, or it failed as well.
 // This doesn't look nice, but it prevents partial responses.
 const RenderError = "500 Internal server error. While handling:\n%s"
 
 // Render a page for passed status code.
+// Clients preferring JSON or plain text get those formats instead of html,
+// see DisableNegotiation.
 // In case of template execution errors,
 // "RenderError" including the original status and message is sent to the client.
+// Returned errors match ErrTemplateExec when the page could not be produced,
+// such as a failing template, Encoder or MaxBufferBytes, or ErrClientWrite
+// when writing it to the client failed, see errors.Is.
+// When w reports the headers were already written, see HeaderWriter,
+// nothing is written and ErrHeadersSent is returned.
+//
+// The page is buffered, so Content-Length is set and the response isn't chunked.
+// For HEAD requests only the status and headers are written.
+// The page is still rendered, for the Content-Length a GET request would get.
 func (p *Pages) Render(w http.ResponseWriter, dp Provider) error {
+	_, err := p.RenderN(w, dp)
+	return err
+}
+
+// RenderN is like Render, but also returns the number of bytes of the body
+// written to the client, like io.Copy.
+// It is the compressed size when the page was compressed,
+// counts RenderError or the FallbackTmpl page on template execution errors
+// and is 0 for HEAD requests and 304 Not Modified.
+func (p *Pages) RenderN(w http.ResponseWriter, dp Provider) (int64, error) {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	set, lang := p.templateSet(dp.Request())
+	name, err := p.render(context.Background(), cw, set, lang, p.format(dp.Request()), dp)
+	return cw.n, p.rendered(dp, name, start, cw.n, err)
+}
+
+// Handle renders the page for an error condition,
+// as a single entry point for handlers and the helpers of this package.
+// A Data is created from r, code and msg and rendered with Render,
+// so Transform, redaction, logging and headers apply like for any other page.
+//
+// err is the optional cause, set as Data.Err. It is logged to ErrorLog
+// and, when code is 0, its status is determined with StatusMapper or StatusForError.
+// If msg is empty, the error's message is used.
+// In DevMode, the stack of the caller is captured in Data.Stack.
+func (p *Pages) Handle(w http.ResponseWriter, r *http.Request, code Status, msg string, err error) error {
+	return p.handle(w, &Data{Req: r, Code: code, Msg: msg}, err)
+}
+
+func (p *Pages) handle(w http.ResponseWriter, d *Data, err error) error {
+	if err != nil {
+		if d.Code == 0 {
+			d.Code = p.statusForError(err)
+		}
+		if d.Msg == "" {
+			d.Msg = err.Error()
+		}
+		d.Err = err
+
+		if p.DevMode && d.Stack == "" {
+			d.Stack = string(debug.Stack())
+		}
+	}
+
+	return p.Render(w, d)
+}
+
+// RenderUsing is like Render, but looks up the page in tmpl instead of Tmpl or Locales.
+// It allows for request scoped template sets, without modifying Pages.
+// All other options of Pages apply.
+func (p *Pages) RenderUsing(w http.ResponseWriter, tmpl *template.Template, dp Provider) error {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	name, err := p.render(context.Background(), cw, tmpl, "", p.format(dp.Request()), dp)
+	return p.rendered(dp, name, start, cw.n, err)
+}
+
+// RenderNamed renders only the body of the page to w, without status or headers,
+// and returns the name of the template used.
+// The name is DefaultTmplName if the built-in DefaultTmpl,
+// or a built-in default of a negotiated format, was used.
+// The body is UTF-8 encoded, Encoders don't apply.
+// Nothing is written to w on template execution errors.
+func (p *Pages) RenderNamed(w io.Writer, dp Provider) (name string, err error) {
+	dp = p.transform(dp)
+
+	set, lang := p.templateSet(dp.Request())
+
 	buf := buffers.Get()
 	defer buffers.Put(buf)
 
-	if err := p.template(dp.Status()).Execute(buf, dp); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, RenderError, dp)
+	if name, err = p.executeFormat(buf, set, p.format(dp.Request()), dp, p.templateData(dp, lang, "")); err != nil {
+		return name, p.logError(err, dp)
+	}
+
+	if _, err = buf.WriteTo(w); err != nil {
+		return name, p.logError(wrapKind(ErrClientWrite, fmt.Errorf("ehtml RenderNamed, write: %w", err)), dp)
+	}
+	return name, nil
+}
+
+// Execute renders only the body of the page to w, like RenderNamed.
+// There are no HTTP side effects, which makes it suitable to generate
+// static pages at build time, such as a "404.html" for a CDN, or for snapshot tests.
+// Without a Request in dp, the page is always html.
+func (p *Pages) Execute(w io.Writer, dp Provider) error {
+	_, err := p.RenderNamed(w, dp)
+	return err
+}
+
+// Prepare returns dp as the Render methods present it to templates and encoders:
+// with Transform, DefaultStatus, RedactFrom, MaxMessageLen and DefaultMessages applied.
+// The error carried by dp, if any, is logged to ErrorLog.
+// It is meant for renderers outside this package, such as ehtmlgrpcweb.
+func (p *Pages) Prepare(dp Provider) Provider {
+	return p.transform(dp)
+}
 
-		return fmt.Errorf("ehtml Render template: %w", err)
+// transform dp using Transform, if set, resolve a zero status, redact its message
+// and substitute an empty message.
+// The error carried by dp, if any, is logged.
+func (p *Pages) transform(dp Provider) Provider {
+	p.logError(errorOf(dp), dp)
+
+	if p.Transform != nil {
+		if t := p.Transform(dp.Request(), dp); t != nil {
+			dp = t
+		}
+	}
+	if dp.Status() == 0 {
+		dp = &zeroStatus{Provider: dp, code: p.defaultStatus()}
 	}
+	return p.defaultMessage(p.truncate(p.redact(dp)))
+}
 
-	w.WriteHeader(dp.Status().Int())
-	if _, err := buf.WriteTo(w); err != nil {
-		return fmt.Errorf("ehtml Render, write to client: %w", err)
+func (p *Pages) defaultStatus() Status {
+	if p.DefaultStatus != 0 {
+		return p.DefaultStatus
 	}
-	return nil
+	return http.StatusInternalServerError
+}
+
+// defaultMessage wraps a Provider which returned an empty message,
+// replacing it with an entry from DefaultMessages.
+type defaultMessage struct {
+	Provider
+	msg string
+}
+
+func (d *defaultMessage) unwrap() Provider { return d.Provider }
+
+// Message implements Provider
+func (d *defaultMessage) Message() string { return d.msg }
+
+func (d *defaultMessage) String() string {
+	s := d.Status()
+	return fmt.Sprintf("%d %s: %s", s, s, d.msg)
+}
+
+// ErrText returns the message of the wrapped error, for `{{ .ErrText }}`.
+func (d *defaultMessage) ErrText() string { return errorText(d.Provider) }
+
+// defaultMessage substitutes an empty message of dp, if DefaultMessages is set.
+func (p *Pages) defaultMessage(dp Provider) Provider {
+	if p.DefaultMessages == nil || dp.Message() != "" {
+		return dp
+	}
+
+	msg, ok := p.DefaultMessages[dp.Status()]
+	if !ok {
+		msg = dp.Status().StringWith(p.StatusText)
+	}
+	return &defaultMessage{Provider: dp, msg: msg}
+}
+
+// zeroStatus wraps a Provider which returned status 0,
+// replacing it with the DefaultStatus.
+type zeroStatus struct {
+	Provider
+	code Status
+}
+
+func (d *zeroStatus) unwrap() Provider { return d.Provider }
+
+// Status implements Provider
+func (d *zeroStatus) Status() Status { return d.code }
+
+func (d *zeroStatus) String() string {
+	return fmt.Sprintf("%d %s: %s", d.code, d.code, d.Message())
+}
+
+// ErrText returns the message of the wrapped error, for `{{ .ErrText }}`.
+func (d *zeroStatus) ErrText() string { return errorText(d.Provider) }
+
+// Funcs adds the elements of funcMap to Tmpl and the sets of Locales,
+// like template.Template.Funcs, and returns p for chaining.
+// When Tmpl is nil, it is created with NewDefaultTmpl and Lang defaults to DefaultLang.
+//
+// The funcs are also added to the templates parsed by a later WatchFS.
+//
+// Funcs must be called before parsing templates which use the funcs into the sets
+// and may not be called while pages are rendered.
+func (p *Pages) Funcs(funcMap template.FuncMap) *Pages {
+	if p.funcs == nil {
+		p.funcs = make(template.FuncMap, len(funcMap))
+	}
+	for name, fn := range funcMap {
+		p.funcs[name] = fn
+	}
+
+	if p.Tmpl == nil {
+		p.Tmpl = NewDefaultTmpl(funcMap)
+		if p.Lang == "" {
+			p.Lang = DefaultLang
+		}
+	} else {
+		p.Tmpl.Funcs(funcMap)
+	}
+
+	for _, set := range p.Locales {
+		if set != nil {
+			set.Funcs(funcMap)
+		}
+	}
+	return p
+}
+
+// templateSet returns the set from Locales matching r with its language tag,
+// or Tmpl and an empty tag.
+func (p *Pages) templateSet(r *http.Request) (*template.Template, string) {
+	if tmpl, lang := p.locale(r); tmpl != nil {
+		return tmpl, lang
+	}
+	return p.base(), ""
+}
+
+// templateData returns the page passed to the templates for dp.
+// It is always a page, so the templates see the same type
+// regardless of the options which apply to dp.
+// locale is the language tag of the template set from Locales, if any.
+// nonce is the CSP nonce of the response, if any.
+func (p *Pages) templateData(dp Provider, locale, nonce string) interface{} {
+	pg := newPage(dp)
+	pg.RateLimit = rateLimitOf(dp)
+	pg.BlockedBy = blockedByOf(dp)
+	pg.BasePath = p.BasePath
+	pg.Nonce = nonce
+	pg.localize = p.LocalizeStatus
+	pg.texts = p.StatusText
+
+	if locale != "" {
+		pg.Lang = locale
+	} else if p.Lang != "" {
+		pg.Lang = p.Lang
+	}
+
+	// The stack is hidden outside DevMode.
+	if p.DevMode {
+		pg.stack = stackOf(dp)
+	}
+	return pg
+}
+
+// newPage returns a page for dp in DefaultLang, without any options applied.
+func newPage(dp Provider) *page {
+	return &page{Provider: unwrapAll(dp), shown: dp, Lang: DefaultLang}
+}
+
+// unwrapAll returns the Provider wrapped by the wrappers of transform.
+func unwrapAll(dp Provider) Provider {
+	for {
+		w, ok := dp.(wrapper)
+		if !ok {
+			return dp
+		}
+		dp = w.unwrap()
+	}
+}
+
+// htmlReplacer escapes like html/template does for
+// text, RCDATA and quoted attribute values.
+var htmlReplacer = strings.NewReplacer(
+	"\x00", "\uFFFD",
+	`"`, "&#34;",
+	"&", "&amp;",
+	"'", "&#39;",
+	"+", "&#43;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// executeDefault writes DefaultTmpl for dp to w, without using text/template.
+// Most of the time of executing a template is spent on reflection,
+// while DefaultTmpl is used by every Pages without templates.
+// The output must be identical to executing defTmpl with a page,
+// which DefaultTmpl always needs for `.Lang`.
+func executeDefault(w io.Writer, dp Provider, data interface{}) error {
+	pg, ok := data.(*page)
+	if !ok {
+		pg = newPage(dp)
+	}
+
+	s := pg.Status()
+	ew := &errWriter{w: w}
+
+	ew.WriteString("<!DOCTYPE html>\n<html lang=\"")
+	ew.WriteEscaped(pg.Lang)
+	code, text, msg := strconv.Itoa(s.Int()), pg.StatusText(), pg.Message()
+
+	ew.WriteString("\">\n<head>\n\t<meta charset=\"utf-8\">\n\t<title>")
+	ew.WriteString(code)
+	ew.WriteString(" ")
+	ew.WriteEscaped(text)
+	ew.WriteString(": ")
+	ew.WriteEscaped(msg)
+	ew.WriteString("</title>\n</head>\n<body>\n\t<h1>")
+	ew.WriteString(code)
+	ew.WriteString(" ")
+	ew.WriteEscaped(text)
+	ew.WriteString("</h1>\n\t<p>")
+	ew.WriteEscaped(msg)
+	ew.WriteString("</p>\n</body>\n</html>")
+
+	return ew.err
+}
+
+// errWriter writes to w until the first error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) WriteString(s string) {
+	if ew.err == nil {
+		_, ew.err = io.WriteString(ew.w, s)
+	}
+}
+
+func (ew *errWriter) WriteEscaped(s string) {
+	if ew.err == nil {
+		_, ew.err = htmlReplacer.WriteString(ew.w, s)
+	}
+}
+
+// isGeneric reports whether name is a template for any status:
+// "error", "error.html" or DefaultTmplName.
+func isGeneric(name string) bool {
+	return name == "error" || name == "error."+formatHTML || name == DefaultTmplName
+}
+
+// fallback calls OnFallback, if set and name is a generic template.
+func (p *Pages) fallback(s Status, name string) {
+	if p.OnFallback != nil && isGeneric(name) {
+		p.OnFallback(s)
+	}
+}
+
+// execute the template for dp from set into buf and returns its name.
+func (p *Pages) execute(buf *bytes.Buffer, set *template.Template, dp Provider, data interface{}) (string, error) {
+	if p.StableETag {
+		p.fingerprint(set)
+	}
+
+	tmpl := p.lookupPage(set, dp)
+	name := tmplName(tmpl)
+	p.fallback(dp.Status(), name)
+
+	var w io.Writer = buf
+	if p.MaxBufferBytes > 0 {
+		w = &limitWriter{buf, p.MaxBufferBytes}
+	}
+
+	if tmpl == defTmpl {
+		if err := executeDefault(w, dp, data); err != nil {
+			return name, wrapKind(ErrTemplateExec, fmt.Errorf("ehtml Render template: %w", err))
+		}
+	} else if err := p.executeLayout(w, set, tmpl, data); err != nil {
+		return name, wrapKind(ErrTemplateExec, fmt.Errorf("ehtml Render template: %w", err))
+	}
+
+	if p.TreatEmptyAsError && buf.Len() == 0 && tmpl != defTmpl {
+		if !isGeneric(name) {
+			p.fallback(dp.Status(), DefaultTmplName)
+		}
+		name = DefaultTmplName
+
+		if err := executeDefault(w, dp, data); err != nil {
+			return name, wrapKind(ErrTemplateExec, fmt.Errorf("ehtml Render template: %w", err))
+		}
+	}
+
+	if p.Sanitizer != nil {
+		b := p.Sanitizer(buf.Bytes())
+		buf.Reset()
+		buf.Write(b)
+	}
+
+	return name, nil
+}
+
+// commonHeaders deletes StripHeaders and sets the headers for all responses,
+// including those of dp.
+func (p *Pages) commonHeaders(h http.Header, lang string, dp Provider) {
+	for _, k := range p.StripHeaders {
+		h.Del(k)
+	}
+	if cc, ok := p.CacheControl[dp.Status()]; ok {
+		h.Set("Cache-Control", cc)
+	}
+	if p.SecurityHeaders {
+		setSecurityHeaders(h)
+	}
+	if p.WWWAuthenticate != "" && httpStatus(dp) == http.StatusUnauthorized && h.Get("WWW-Authenticate") == "" {
+		h.Set("WWW-Authenticate", p.WWWAuthenticate)
+	}
+	for _, ph := range providerHeaders(dp) {
+		for k, v := range ph {
+			h[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+		}
+	}
+	if len(p.Locales) > 0 && dp.Request() != nil {
+		addVary(h, "Accept-Language")
+	}
+	if lang != "" {
+		h.Set("Content-Language", lang)
+	}
+	if !p.DisableNoSniff {
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+}
+
+// pageHeaders sets the headers limited by MaxHeaderBytes
+// and returns the template data for dp, see templateData.
+func (p *Pages) pageHeaders(h http.Header, dp Provider, lang string) interface{} {
+	hs := p.headerSetter(h)
+	nonce := p.setCSP(hs)
+
+	data := p.templateData(dp, lang, nonce)
+	if pg, ok := data.(*page); ok {
+		if pg.RateLimit != nil {
+			pg.RateLimit.setHeaders(hs)
+		}
+		if pg.BlockedBy != nil {
+			pg.BlockedBy.setHeaders(hs)
+		}
+	}
+	return data
+}
+
+// render the page for dp in format from set and return the name of the executed template,
+// which is empty if none was executed.
+func (p *Pages) render(ctx context.Context, w http.ResponseWriter, set *template.Template, lang, format string, dp Provider) (string, error) {
+	if headersSent(w) {
+		return "", ErrHeadersSent
+	}
+	bypassIntercept(w)
+
+	// Headers are restored when ctx is done before the page is written,
+	// so the caller can write another response.
+	var orig http.Header
+	if ctx.Done() != nil {
+		orig = w.Header().Clone()
+	}
+	p.commonHeaders(w.Header(), lang, dp)
+
+	if target, ok := p.Redirects[dp.Status()]; ok && dp.Request() != nil {
+		p.redirect(w, dp.Request(), target, dp)
+		return "", nil
+	}
+
+	p.varyAccept(w.Header(), dp.Request())
+	data := p.pageHeaders(w.Header(), dp, lang)
+
+	var enc Encoder
+	if format == formatHTML {
+		enc = p.Encoders[dp.Status()]
+	}
+
+	if p.notModified(w.Header(), set, format, enc, lang, dp) {
+		w.WriteHeader(http.StatusNotModified)
+		return "", nil
+	}
+
+	p.setContentType(w.Header(), dp, format, enc)
+
+	if err := ctx.Err(); err != nil {
+		restoreHeader(w.Header(), orig)
+		return "", fmt.Errorf("ehtml Render, before execute: %w", err)
+	}
+
+	buf := buffers.Get()
+	defer buffers.Put(buf)
+
+	name, err := p.executeFormat(buf, set, format, dp, data)
+	if err != nil {
+		return name, p.renderError(w, dp, data, err)
+	}
+
+	if enc != nil {
+		b, err := enc.Encode(buf.Bytes())
+		if err != nil {
+			return name, p.renderError(w, dp, data, fmt.Errorf("ehtml Render encode %s: %w", enc.Charset(), err))
+		}
+
+		buf.Reset()
+		buf.Write(b)
+	}
+
+	p.logBody(buf.Bytes(), dp)
+
+	if p.bodyNotModified(w.Header(), dp, buf.Bytes()) {
+		w.WriteHeader(http.StatusNotModified)
+		return name, nil
+	}
+
+	if p.compress(w.Header(), dp, buf.Len()) {
+		if err := gzipBuffer(buf); err != nil {
+			return name, p.renderError(w, dp, data, err)
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	if err := ctx.Err(); err != nil {
+		restoreHeader(w.Header(), orig)
+		return name, fmt.Errorf("ehtml Render, before write: %w", err)
+	}
+
+	if err := p.writeBody(w, dp, buf); err != nil {
+		return name, wrapKind(ErrClientWrite, fmt.Errorf("ehtml Render, write to client: %w", err))
+	}
+	return name, nil
+}
+
+// restoreHeader replaces the values in h with those of orig.
+func restoreHeader(h, orig http.Header) {
+	for k := range h {
+		delete(h, k)
+	}
+	for k, v := range orig {
+		h[k] = v
+	}
+}
+
+// writeBody sends the final body in buf, after all headers are set:
+// Content-Length is set to its size before the status of dp is written,
+// as headers can't change after that. The body is not written for HEAD requests.
+func (p *Pages) writeBody(w http.ResponseWriter, dp Provider, buf *bytes.Buffer) error {
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	p.writeHeader(w, dp)
+
+	if r := dp.Request(); r != nil && r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// setContentType sets the Content-Type header for format,
+// unless dp provides one through HeaderProvider or MetaProvider.
+func (p *Pages) setContentType(h http.Header, dp Provider, format string, enc Encoder) {
+	for _, ph := range providerHeaders(dp) {
+		if ph.Get("Content-Type") != "" {
+			return
+		}
+	}
+
+	if format != formatHTML {
+		h.Set("Content-Type", contentType(format))
+		return
+	}
+
+	charset := p.Charset
+	if enc != nil {
+		charset = enc.Charset()
+	} else if charset == "" {
+		charset = DefaultCharset
+	}
+	h.Set("Content-Type", "text/html; charset="+charset)
+}
+
+// writeHeader writes the status of dp, unless deferred by DeferHeader.
+func (p *Pages) writeHeader(w http.ResponseWriter, dp Provider) {
+	s := httpStatus(dp)
+	if p.DeferHeader && s == http.StatusOK {
+		return
+	}
+	w.WriteHeader(s.Int())
+}
+
+// renderError sends the page from FallbackTmpl, or RenderError, to the client and returns err, matching ErrTemplateExec.
+// FallbackTmpl is executed with data, the data of the page which failed.
+// Caching headers of the page are removed, so the failure isn't cached or revalidated.
+func (p *Pages) renderError(w http.ResponseWriter, dp Provider, data interface{}, err error) error {
+	if !errors.Is(err, ErrTemplateExec) {
+		err = wrapKind(ErrTemplateExec, err)
+	}
+	for _, k := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+		w.Header().Del(k)
+	}
+
+	if p.FallbackTmpl != nil {
+		buf := buffers.Get()
+		defer buffers.Put(buf)
+
+		ferr := p.FallbackTmpl.Execute(buf, data)
+		if ferr == nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+			w.WriteHeader(http.StatusInternalServerError)
+			buf.WriteTo(w)
+
+			return err
+		}
+		err = errors.Join(err, fmt.Errorf("ehtml FallbackTmpl: %w", ferr))
+	}
+
+	buf := buffers.Get()
+	defer buffers.Put(buf)
+	fmt.Fprintf(buf, RenderError, dp.String())
+
+	// RenderError is plain text, replacing the Content-Type of the page.
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusInternalServerError)
+	buf.WriteTo(w)
+
+	return err
 }
@@ -6,6 +6,7 @@ package ehtml
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
@@ -15,6 +16,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -46,6 +49,35 @@ func TestStatus_String(t *testing.T) {
 	}
 }
 
+func TestStatus_StringWith(t *testing.T) {
+	texts := map[Status]string{
+		529:                 "Site Overloaded",
+		http.StatusNotFound: "Nothing here",
+		http.StatusGone:     "",
+	}
+
+	tests := []struct {
+		name   string
+		status Status
+		texts  map[Status]string
+		want   string
+	}{
+		{"Custom", 529, texts, "Site Overloaded"},
+		{"Override", http.StatusNotFound, texts, "Nothing here"},
+		{"Empty entry", http.StatusGone, texts, "Gone"},
+		{"No entry", http.StatusTeapot, texts, "I'm a teapot"},
+		{"Unknown", 900, texts, ""},
+		{"Nil map", http.StatusNotFound, nil, "Not Found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.StringWith(tt.texts); got != tt.want {
+				t.Errorf("Status.StringWith() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStatus_int(t *testing.T) {
 	tests := []struct {
 		name string
@@ -67,6 +99,65 @@ func TestStatus_int(t *testing.T) {
 	}
 }
 
+func TestStatus_IsTimeout(t *testing.T) {
+	tests := []struct {
+		s    Status
+		want bool
+	}{
+		{http.StatusRequestTimeout, true},
+		{http.StatusGatewayTimeout, true},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s.toA(), func(t *testing.T) {
+			if got := tt.s.IsTimeout(); got != tt.want {
+				t.Errorf("Status.IsTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus_Class(t *testing.T) {
+	tests := []struct {
+		s               Status
+		wantClass       int
+		wantRedirect    bool
+		wantClientError bool
+		wantServerError bool
+	}{
+		{0, 0, false, false, false},
+		{99, 0, false, false, false},
+		{100, 1, false, false, false},
+		{299, 2, false, false, false},
+		{300, 3, true, false, false},
+		{399, 3, true, false, false},
+		{400, 4, false, true, false},
+		{499, 4, false, true, false},
+		{500, 5, false, false, true},
+		{599, 5, false, false, true},
+		{600, 6, false, false, false},
+		{999, 9, false, false, false},
+		{1000, 0, false, false, false},
+		{-404, 0, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s.toA(), func(t *testing.T) {
+			if got := tt.s.Class(); got != tt.wantClass {
+				t.Errorf("Status.Class() = %v, want %v", got, tt.wantClass)
+			}
+			if got := tt.s.IsRedirect(); got != tt.wantRedirect {
+				t.Errorf("Status.IsRedirect() = %v, want %v", got, tt.wantRedirect)
+			}
+			if got := tt.s.IsClientError(); got != tt.wantClientError {
+				t.Errorf("Status.IsClientError() = %v, want %v", got, tt.wantClientError)
+			}
+			if got := tt.s.IsServerError(); got != tt.wantServerError {
+				t.Errorf("Status.IsServerError() = %v, want %v", got, tt.wantServerError)
+			}
+		})
+	}
+}
+
 func TestStatus_toA(t *testing.T) {
 	tests := []struct {
 		name string
@@ -110,6 +201,136 @@ func TestData_Message(t *testing.T) {
 	}
 }
 
+func TestData_Source(t *testing.T) {
+	d := &Data{Src: "users.Get"}
+	if got := d.Source(); got != "users.Get" {
+		t.Errorf("Data.Source() = %v, want %v", got, "users.Get")
+	}
+}
+
+func TestData_Headers(t *testing.T) {
+	d := &Data{Hdr: http.Header{"Allow": {"GET, HEAD"}}}
+	if got := d.Headers(); !reflect.DeepEqual(got, d.Hdr) {
+		t.Errorf("Data.Headers() = %v, want %v", got, d.Hdr)
+	}
+}
+
+func TestPages_Render_Headers(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     *template.Template
+		code     Status
+		wantCode int
+		wantType string
+	}{
+		{
+			"Success",
+			nil,
+			http.StatusServiceUnavailable,
+			http.StatusServiceUnavailable,
+			"text/html; charset=utf-8",
+		},
+		{
+			"RenderError",
+			template.Must(template.New("error").Parse("{{ .Missing }}")),
+			http.StatusServiceUnavailable,
+			http.StatusInternalServerError,
+			"text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:         tt.tmpl,
+				StripHeaders: []string{"Retry-After"},
+			}
+			d := &Data{
+				Code: tt.code,
+				Msg:  "Maintenance",
+				Hdr:  http.Header{"retry-after": {"120"}},
+			}
+
+			w := httptest.NewRecorder()
+			w.Header().Set("Retry-After", "1")
+			p.Render(w, d)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Values("Retry-After"); !reflect.DeepEqual(got, []string{"120"}) {
+				t.Errorf("Pages.Render() header Retry-After = %v, want %v", got, []string{"120"})
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Pages.Render() Content-Type = %v, want %v", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestData_IsTLS(t *testing.T) {
+	tlsReq := httptest.NewRequest("GET", "https://example.com/foo", nil)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want bool
+	}{
+		{"Nil request", nil, false},
+		{"Plaintext", httptest.NewRequest("GET", "http://example.com/foo", nil), false},
+		{"TLS", tlsReq, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Req: tt.req}
+			if got := d.IsTLS(); got != tt.want {
+				t.Errorf("Data.IsTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestData_Proto(t *testing.T) {
+	h2Req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	h2Req.Proto = "HTTP/2.0"
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"Nil request", nil, ""},
+		{"HTTP/1.1", httptest.NewRequest("GET", "http://example.com/foo", nil), "HTTP/1.1"},
+		{"HTTP/2.0", h2Req, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Req: tt.req}
+			if got := d.Proto(); got != tt.want {
+				t.Errorf("Data.Proto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestData_RemoteAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"Nil request", nil, ""},
+		{"Request", httptest.NewRequest("GET", "http://example.com/foo", nil), "192.0.2.1:1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Req: tt.req}
+			if got := d.RemoteAddr(); got != tt.want {
+				t.Errorf("Data.RemoteAddr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestData_String(t *testing.T) {
 	type fields struct {
 		Code Status
@@ -232,6 +453,38 @@ func TestPages_template(t *testing.T) {
 	}
 }
 
+func Test_lookupSet(t *testing.T) {
+	set := template.Must(template.New("error").Parse(
+		`{{ define "404" }}404{{ end }}{{ define "timeout" }}timeout{{ end }}{{ define "4xx" }}4xx{{ end }}{{ define "error" }}error{{ end }}`,
+	))
+
+	tests := []struct {
+		name string
+		set  *template.Template
+		s    Status
+		want string
+	}{
+		{"Nil", nil, http.StatusNotFound, ""},
+		{"Code", set, http.StatusNotFound, "404"},
+		{"Timeout", set, http.StatusRequestTimeout, "timeout"},
+		{"Class", set, http.StatusBadRequest, "4xx"},
+		{"Gateway timeout", set, http.StatusGatewayTimeout, "timeout"},
+		{"Undefined class", set, http.StatusInternalServerError, "error"},
+		{"Out of range", set, 42, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if tmpl := lookupSet(tt.set, tt.s); tmpl != nil {
+				got = tmpl.Name()
+			}
+			if got != tt.want {
+				t.Errorf("lookupSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPages_Render(t *testing.T) {
 	errTmpl := template.Must(template.New("error").Parse("{{ .Missing }}"))
 
@@ -293,97 +546,1520 @@ func TestPages_Render(t *testing.T) {
 	}
 }
 
-type errorWriter struct{}
-
-func (errorWriter) Header() http.Header       { return nil }
-func (errorWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
-func (errorWriter) WriteHeader(int)           {}
+func TestPages_Render_Head(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantBody bool
+	}{
+		{
+			"HEAD",
+			httptest.NewRequest("HEAD", "http://example.com/foo", nil),
+			false,
+		},
+		{
+			"GET",
+			httptest.NewRequest("GET", "http://example.com/foo", nil),
+			true,
+		},
+		{
+			"Nil request",
+			nil,
+			true,
+		},
+	}
 
-func TestPages_Render_WriteError(t *testing.T) {
 	p := &Pages{}
-	d := &Data{
-		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
-		Code: http.StatusTeapot,
-		Msg:  "Foo bar",
-	}
-	if err := p.Render(errorWriter{}, d); !errors.Is(err, io.ErrClosedPipe) {
-		t.Errorf("Pages.Render() error = %v, wantErr %v", err, io.ErrClosedPipe)
+	var get bytes.Buffer
+	if _, err := p.RenderNamed(&get, &Data{Code: http.StatusNotFound, Msg: "Foo bar"}); err != nil {
+		t.Fatal(err)
 	}
-}
+	wantLength := strconv.Itoa(get.Len())
 
-const exampleTemplates = `
-{{- define "head" -}}
-<head>
-	<meta charset="utf-8">
-	<title>{{ .String }}</title>
-</head>
-{{- end -}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{
+				Req:  tt.req,
+				Code: http.StatusNotFound,
+				Msg:  "Foo bar",
+			}
 
-{{- define "error" -}}
-<!DOCTYPE html>
-<html lang="en">
-{{ template "head" . }}
-<body>
-	<h1>{{ .Status.Int }} {{ .Status }}</h1>
-	<p>
-		{{ .Message }} while serving {{ .Request.URL.Path }}.
-		Request ID: {{ .ReqID }}
-	</p>
-	<p><i>This is a generic error page</i><p>
-</body>
-</html>
-{{- end -}}
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
 
-{{- define "500" -}}
-<!DOCTYPE html>
-<html lang="en">
-{{ template "head" . }}
-<body>
-	<h1>Snap!</h1>
-	<h2>{{ .Status.Int }} {{ .Status }}</h2>
-	<p>
-		Something went really wrong and we've been notified!
-		Please try again later.
-	</p>
-	<p><i>
-		Error: {{ .Message }} while serving {{ .Request.URL.Path }}.
-		Request ID: {{ .ReqID }}
-	</i></p>
-</body>
-</html>
-{{- end -}}
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
 
-{{- define "404" -}}
-<!DOCTYPE html>
-<html lang="en">
-<head>
-	{{ template "head" . }}
-</head>
-<body>
-	<h1>{{ .Status.Int}} {{ .Status }}</h1>
-	<p>
-		{{ .Request.URL.Path }} could not be found.
-	</p>
-</body>
-</html>
-{{- end -}}`
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("Pages.Render() status = %v, want: %v", resp.StatusCode, http.StatusNotFound)
+			}
+			if (len(body) > 0) != tt.wantBody {
+				t.Errorf("Pages.Render() body = %q, wantBody %v", body, tt.wantBody)
+			}
+			if got := resp.Header.Get("Content-Length"); got != wantLength {
+				t.Errorf("Pages.Render() Content-Length = %v, want %v", got, wantLength)
+			}
+		})
+	}
+}
 
-func Example() {
-	p := &Pages{template.Must(template.New("error").Parse(exampleTemplates))}
+func TestPages_Render_ContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Pages
+		hdr  http.Header
+		want string
+	}{
+		{
+			"Default",
+			&Pages{},
+			nil,
+			"text/html; charset=utf-8",
+		},
+		{
+			"Charset",
+			&Pages{Charset: "iso-8859-1"},
+			nil,
+			"text/html; charset=iso-8859-1",
+		},
+		{
+			"Headers",
+			&Pages{},
+			http.Header{"Content-Type": {"application/xhtml+xml"}},
+			"application/xhtml+xml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.p.Render(w, &Data{Code: http.StatusNotFound, Hdr: tt.hdr}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.want {
+				t.Errorf("Pages.Render() Content-Type = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_StripHeaders(t *testing.T) {
+	p := &Pages{
+		StripHeaders: []string{"Cache-Control", "ETag"},
+	}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusNotFound,
+	}
 
-	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
 	w := httptest.NewRecorder()
+	w.Header().Set("Cache-Control", "max-age=3600")
+	w.Header().Set("Etag", `"foo"`)
+	w.Header().Set("X-Foo", "Bar")
 
-	// Extend Data, as needed
-	type data struct {
-		Data
-		ReqID int
+	if err := p.Render(w, d); err != nil {
+		t.Fatal(err)
 	}
 
-	// Serves the client with the "500" template
-	err := p.Render(w, &data{Data{req, http.StatusInternalServerError, "DB connection"}, 666})
-	if err != nil {
-		log.Println(err)
+	want := http.Header{
+		"X-Foo":                  []string{"Bar"},
+		"Content-Type":           []string{"text/html; charset=utf-8"},
+		"Content-Length":         []string{strconv.Itoa(w.Body.Len())},
+		"X-Content-Type-Options": []string{"nosniff"},
+		"Vary":                   []string{"Accept"},
+	}
+	if got := w.Result().Header; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pages.Render() headers = %v, want %v", got, want)
+	}
+}
+
+func TestPages_ContentLength(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse("{{ .Message }}"))
+
+	tests := []struct {
+		name   string
+		render func(w http.ResponseWriter, r *http.Request) error
+	}{
+		{"Render", func(w http.ResponseWriter, r *http.Request) error {
+			return (&Pages{Tmpl: tmpl}).Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Not here"})
+		}},
+		{"RenderError", func(w http.ResponseWriter, r *http.Request) error {
+			(&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Foo }}"))}).Render(w, &Data{Req: r, Code: http.StatusNotFound})
+			return nil
+		}},
+		{"RenderProblem", func(w http.ResponseWriter, r *http.Request) error {
+			return (&Pages{}).RenderProblem(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Not here"})
+		}},
+		{"RenderMultipart", func(w http.ResponseWriter, r *http.Request) error {
+			r.Header.Set("Accept", "multipart/mixed")
+			return (&Pages{Tmpl: tmpl, EnableMultipart: true}).RenderMultipart(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Not here"}, r)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.render(w, httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+				t.Fatal(err)
+			}
+			if w.Body.Len() == 0 {
+				t.Fatal("empty body")
+			}
+			if got, want := w.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+				t.Errorf("Content-Length = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_WWWAuthenticate(t *testing.T) {
+	const basic = `Basic realm="admin"`
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+		hdr   http.Header
+		set   string
+		want  string
+	}{
+		{"Unauthorized", &Pages{WWWAuthenticate: basic}, http.StatusUnauthorized, nil, "", basic},
+		{"Forbidden", &Pages{WWWAuthenticate: basic}, http.StatusForbidden, nil, "", ""},
+		{"Not set", &Pages{}, http.StatusUnauthorized, nil, "", ""},
+		{"Already set", &Pages{WWWAuthenticate: basic}, http.StatusUnauthorized, nil, "Bearer", "Bearer"},
+		{
+			"HeaderProvider",
+			&Pages{WWWAuthenticate: basic},
+			http.StatusUnauthorized,
+			http.Header{"Www-Authenticate": {`Bearer realm="api"`}},
+			"",
+			`Bearer realm="api"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if tt.set != "" {
+				w.Header().Set("WWW-Authenticate", tt.set)
+			}
+
+			if err := tt.pages.Render(w, &Data{Code: tt.code, Hdr: tt.hdr}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("Pages.Render() WWW-Authenticate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_CacheControl(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+		hdr   http.Header
+		ifNM  bool
+		want  string
+	}{
+		{
+			"No store",
+			&Pages{CacheControl: map[Status]string{http.StatusNotFound: "no-store"}},
+			http.StatusNotFound,
+			nil,
+			false,
+			"no-store",
+		},
+		{
+			"Max age",
+			&Pages{CacheControl: map[Status]string{http.StatusServiceUnavailable: "max-age=30"}},
+			http.StatusServiceUnavailable,
+			nil,
+			false,
+			"max-age=30",
+		},
+		{
+			"No entry",
+			&Pages{CacheControl: map[Status]string{http.StatusNotFound: "no-store"}},
+			http.StatusGone,
+			nil,
+			false,
+			"max-age=3600",
+		},
+		{
+			"HeaderProvider",
+			&Pages{CacheControl: map[Status]string{http.StatusNotFound: "no-store"}},
+			http.StatusNotFound,
+			http.Header{"Cache-Control": {"private"}},
+			false,
+			"private",
+		},
+		{
+			"Not modified",
+			&Pages{CacheControl: map[Status]string{http.StatusNotFound: "no-cache"}, EnableETag: true},
+			http.StatusNotFound,
+			nil,
+			true,
+			"no-cache",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Req: httptest.NewRequest(http.MethodGet, "/", nil), Code: tt.code, Hdr: tt.hdr}
+
+			if tt.ifNM {
+				w := httptest.NewRecorder()
+				if err := tt.pages.Render(w, d); err != nil {
+					t.Fatal(err)
+				}
+				d.Req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+			}
+
+			w := httptest.NewRecorder()
+			w.Header().Set("Cache-Control", "max-age=3600")
+
+			if err := tt.pages.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+			if tt.ifNM && w.Code != http.StatusNotModified {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, http.StatusNotModified)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Pages.Render() Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_NoSniff(t *testing.T) {
+	errTmpl := template.Must(template.New("error").Parse("{{ .Missing }}"))
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		req   *http.Request
+		want  string
+	}{
+		{
+			"Default",
+			&Pages{},
+			httptest.NewRequest("GET", "http://example.com/foo", nil),
+			"nosniff",
+		},
+		{
+			"Render error",
+			&Pages{Tmpl: errTmpl},
+			httptest.NewRequest("GET", "http://example.com/foo", nil),
+			"nosniff",
+		},
+		{
+			"HEAD",
+			&Pages{},
+			httptest.NewRequest("HEAD", "http://example.com/foo", nil),
+			"nosniff",
+		},
+		{
+			"Disabled",
+			&Pages{DisableNoSniff: true},
+			httptest.NewRequest("GET", "http://example.com/foo", nil),
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.pages.Render(w, &Data{Req: tt.req, Code: http.StatusNotFound})
+
+			if got := w.Result().Header.Get("X-Content-Type-Options"); got != tt.want {
+				t.Errorf("Pages.Render() X-Content-Type-Options = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_Sanitizer(t *testing.T) {
+	p := &Pages{
+		Tmpl: template.Must(template.New("error").Parse(`<p>{{ .Message }}</p><script>alert("foo")</script>`)),
+		Sanitizer: func(page []byte) []byte {
+			if i := bytes.Index(page, []byte("<script>")); i >= 0 {
+				return page[:i]
+			}
+			return page
+		},
+	}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusBadRequest,
+		Msg:  "Foo bar",
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := w.Body.String(), "<p>Foo bar</p>"; got != want {
+		t.Errorf("Pages.Render() = %v, want %v", got, want)
+	}
+}
+
+func TestPages_Render_BasePath(t *testing.T) {
+	p := &Pages{
+		Tmpl:     template.Must(template.New("error").Parse(`<link href="{{ .BasePath }}/static/error.css"><p>{{ .Provider.Message }}</p>`)),
+		BasePath: "/app",
+	}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/app/foo", nil),
+		Code: http.StatusBadRequest,
+		Msg:  "Foo bar",
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := w.Body.String(), `<link href="/app/static/error.css"><p>Foo bar</p>`; got != want {
+		t.Errorf("Pages.Render() = %v, want %v", got, want)
+	}
+}
+
+func TestPages_RenderNamed(t *testing.T) {
+	errTmpl := template.Must(template.New("error").Parse("{{ .Missing }}"))
+
+	tests := []struct {
+		name     string
+		tmpl     *template.Template
+		code     Status
+		wantName string
+		want     string
+		wantErr  bool
+	}{
+		{
+			"Status template",
+			testTmpl,
+			http.StatusNotFound,
+			"404",
+			"404 template",
+			false,
+		},
+		{
+			"Generic template",
+			testTmpl,
+			http.StatusBadRequest,
+			"error",
+			"Generic template",
+			false,
+		},
+		{
+			"Default template",
+			nil,
+			http.StatusNotFound,
+			DefaultTmplName,
+			defaultTmplOut,
+			false,
+		},
+		{
+			"Execution error",
+			errTmpl,
+			http.StatusNotFound,
+			"error",
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: tt.tmpl}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+				Msg:  "Foo bar",
+			}
+
+			var buf bytes.Buffer
+			name, err := p.RenderNamed(&buf, d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pages.RenderNamed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName {
+				t.Errorf("Pages.RenderNamed() name = %v, want %v", name, tt.wantName)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.RenderNamed() = \n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_RenderNamed_WriteError(t *testing.T) {
+	p := &Pages{}
+	d := &Data{Code: http.StatusTeapot}
+
+	if _, err := p.RenderNamed(errorWriter{}, d); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Pages.RenderNamed() error = %v, wantErr %v", err, io.ErrClosedPipe)
+	}
+}
+
+// namedData selects a template by name.
+type namedData struct {
+	Data
+	name string
+}
+
+func (d *namedData) TemplateName() string { return d.name }
+
+func TestPages_Render_TemplateNamer(t *testing.T) {
+	tmpl := template.Must(template.New("").Parse(
+		`{{ define "maintenance" }}Back soon{{ end }}{{ define "503" }}503{{ end }}{{ define "error" }}error{{ end }}`,
+	))
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		dp    Provider
+		want  string
+	}{
+		{"Named", &Pages{Tmpl: tmpl}, &namedData{Data{Code: http.StatusServiceUnavailable}, "maintenance"}, "Back soon"},
+		{"Other status", &Pages{Tmpl: tmpl}, &namedData{Data{Code: http.StatusBadGateway}, "maintenance"}, "Back soon"},
+		{"Redacted", &Pages{Tmpl: tmpl, RedactFrom: DefaultRedactFrom}, &namedData{Data{Code: http.StatusBadGateway, Msg: "secret"}, "maintenance"}, "Back soon"},
+		{"Undefined", &Pages{Tmpl: tmpl}, &namedData{Data{Code: http.StatusServiceUnavailable}, "upgrade"}, "503"},
+		{"Empty", &Pages{Tmpl: tmpl}, &namedData{Data{Code: http.StatusBadGateway}, ""}, "error"},
+		{"Nil Tmpl", &Pages{}, &namedData{Data{Code: http.StatusServiceUnavailable}, "maintenance"}, "<h1>503 Service Unavailable</h1>"},
+		{"Not a TemplateNamer", &Pages{Tmpl: tmpl}, &Data{Code: http.StatusServiceUnavailable}, "503"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, tt.dp); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_OnFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		tmpl  string
+		empty bool
+		code  Status
+		want  []Status
+	}{
+		{"Code", `{{ define "404" }}404{{ end }}{{ define "error" }}error{{ end }}`, false, http.StatusNotFound, nil},
+		{"Class", `{{ define "4xx" }}4xx{{ end }}{{ define "error" }}error{{ end }}`, false, http.StatusNotFound, nil},
+		{"Timeout", `{{ define "timeout" }}timeout{{ end }}`, false, http.StatusGatewayTimeout, nil},
+		{"Error", `{{ define "404" }}404{{ end }}{{ define "error" }}error{{ end }}`, false, http.StatusGone, []Status{http.StatusGone}},
+		{"Error html", `{{ define "error.html" }}error{{ end }}`, false, http.StatusGone, []Status{http.StatusGone}},
+		{"Default", `{{ define "404" }}404{{ end }}`, false, http.StatusGone, []Status{http.StatusGone}},
+		{"Empty", `{{ define "404" }}{{ end }}`, true, http.StatusNotFound, []Status{http.StatusNotFound}},
+		{"Empty error", `{{ define "error" }}{{ end }}`, true, http.StatusNotFound, []Status{http.StatusNotFound}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Status
+			p := &Pages{
+				Tmpl:              template.Must(template.New("").Parse(tt.tmpl)),
+				TreatEmptyAsError: tt.empty,
+				OnFallback:        func(s Status) { got = append(got, s) },
+			}
+
+			if err := p.Render(httptest.NewRecorder(), &Data{Code: tt.code}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pages.Render() OnFallback = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_TreatEmptyAsError(t *testing.T) {
+	emptyTmpl := template.Must(template.New("404").Parse(`{{ define "foo" }}Foo{{ end }}`))
+
+	tests := []struct {
+		name              string
+		treatEmptyAsError bool
+		wantName          string
+		want              string
+	}{
+		{
+			"Disabled",
+			false,
+			"404",
+			"",
+		},
+		{
+			"Enabled",
+			true,
+			DefaultTmplName,
+			defaultTmplOut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:              emptyTmpl,
+				TreatEmptyAsError: tt.treatEmptyAsError,
+			}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: http.StatusNotFound,
+				Msg:  "Foo bar",
+			}
+
+			var buf bytes.Buffer
+			name, err := p.RenderNamed(&buf, d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.wantName {
+				t.Errorf("Pages.RenderNamed() name = %v, want %v", name, tt.wantName)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.RenderNamed() = \n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_MaxBufferBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		wantCode int
+		wantErr  error
+	}{
+		{
+			"Unlimited",
+			0,
+			http.StatusNotFound,
+			nil,
+		},
+		{
+			"Within limit",
+			len(defaultTmplOut),
+			http.StatusNotFound,
+			nil,
+		},
+		{
+			"Exceeded",
+			100,
+			http.StatusInternalServerError,
+			ErrMaxBufferBytes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{MaxBufferBytes: tt.max}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: http.StatusNotFound,
+				Msg:  "Foo bar",
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pages.Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want: %v", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPages_Render_DefaultTmplSet(t *testing.T) {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse(DefaultTmpl))}
+	w := httptest.NewRecorder()
+
+	if err := p.Render(w, &Data{Code: http.StatusGone, Msg: "Moved on"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var want bytes.Buffer
+	if err := executeDefault(&want, &Data{Code: http.StatusGone, Msg: "Moved on"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != want.String() {
+		t.Errorf("Pages.Render() =\n%s\nwant\n%s", got, want.String())
+	}
+}
+
+func TestPages_Render_Lang(t *testing.T) {
+	tests := []struct {
+		name   string
+		pages  *Pages
+		accept string
+		want   string
+	}{
+		{
+			"Default",
+			&Pages{},
+			"",
+			`<html lang="en">`,
+		},
+		{
+			"Lang",
+			&Pages{Lang: "nl"},
+			"",
+			`<html lang="nl">`,
+		},
+		{
+			"Locale",
+			&Pages{
+				Lang: "nl",
+				Locales: map[string]*template.Template{
+					"de": template.Must(template.New("error").Parse(`{{ .Lang }}`)),
+				},
+			},
+			"de-CH",
+			"de",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			r.Header.Set("Accept-Language", tt.accept)
+
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, &Data{Req: r, Code: http.StatusNotFound}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("Pages.Render() = \n%v\nwant containing\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+type reqIDData struct {
+	Provider
+	ReqID int
+}
+
+func TestPages_Render_Transform(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{ .Message }}{{ with .Provider.ReqID }} ({{ . }}){{ end }}`))
+
+	tests := []struct {
+		name      string
+		transform func(*http.Request, Provider) Provider
+		want      string
+	}{
+		{
+			"Wrap",
+			func(r *http.Request, dp Provider) Provider { return &reqIDData{dp, 666} },
+			"Foo bar (666)",
+		},
+		{
+			"Replace",
+			func(r *http.Request, dp Provider) Provider {
+				return &reqIDData{&Data{Req: r, Code: dp.Status(), Msg: "Replaced"}, 0}
+			},
+			"Replaced",
+		},
+		{
+			"Nil",
+			func(r *http.Request, dp Provider) Provider { return nil },
+			"Foo bar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:      tmpl,
+				Transform: tt.transform,
+			}
+			d := &reqIDData{
+				&Data{
+					Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+					Code: http.StatusNotFound,
+					Msg:  "Foo bar",
+				},
+				0,
+			}
+
+			var buf bytes.Buffer
+			if _, err := p.RenderNamed(&buf, d); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.RenderNamed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Handle(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     Status
+		msg      string
+		err      error
+		wantCode int
+		wantBody string
+		wantLog  string
+	}{
+		{
+			"Status",
+			http.StatusNotFound,
+			"Foo bar",
+			nil,
+			http.StatusNotFound,
+			"404 Foo bar",
+			"",
+		},
+		{
+			"Error",
+			0,
+			"",
+			context.DeadlineExceeded,
+			http.StatusGatewayTimeout,
+			"504 context deadline exceeded",
+			"context deadline exceeded; while rendering 504 Gateway Timeout: context deadline exceeded\n",
+		},
+		{
+			"Error with status and message",
+			http.StatusBadGateway,
+			"Upstream down",
+			errors.New("dial tcp: connection refused"),
+			http.StatusBadGateway,
+			"502 Upstream down",
+			"dial tcp: connection refused; while rendering 502 Bad Gateway: Upstream down\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &Pages{
+				Tmpl:       template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}")),
+				RedactFrom: -1,
+				ErrorLog:   log.New(&buf, "", 0),
+			}
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := httptest.NewRecorder()
+
+			if err := p.Handle(w, r, tt.code, tt.msg, tt.err); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Handle() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.Handle() = %v, want %v", got, tt.wantBody)
+			}
+			if got := buf.String(); got != tt.wantLog {
+				t.Errorf("Pages.Handle() log = %q, want %q", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestData_ErrText(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+
+	d := &Data{Code: http.StatusBadGateway, Msg: "Upstream down", Err: fmt.Errorf("fetch: %w", cause)}
+	if !errors.Is(d.Unwrap(), cause) {
+		t.Error("errors.Is(Data.Unwrap(), cause) = false, want true")
+	}
+	if got, want := d.ErrText(), "fetch: dial tcp: connection refused"; got != want {
+		t.Errorf("Data.ErrText() = %q, want %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%v", d), "502 Bad Gateway: Upstream down"; got != want {
+		t.Errorf("fmt.Sprintf(%%v, Data) = %q, want %q", got, want)
+	}
+	if got, want := d.String(), "502 Bad Gateway: Upstream down"; got != want {
+		t.Errorf("Data.String() = %q, want %q", got, want)
+	}
+
+	if got := (&Data{}).ErrText(); got != "" {
+		t.Errorf("Data.ErrText() = %q, want empty", got)
+	}
+}
+
+type reqIDKey struct{}
+
+func TestNewData(t *testing.T) {
+	RegisterRequestIDKey(reqIDKey{})
+	t.Cleanup(func() { RegisterRequestIDKey(nil) })
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), reqIDKey{}, 666))
+
+	tests := []struct {
+		name string
+		data *Data
+		want string
+	}{
+		{"NewData", NewData(r, http.StatusNotFound, "Foo"), "666"},
+		{"Nil request", NewData(nil, http.StatusNotFound, "Foo"), ""},
+		{"Literal", &Data{Req: r}, "666"},
+		{"ID", &Data{Req: r, ID: "abc"}, "abc"},
+		{"No ID in context", NewData(httptest.NewRequest(http.MethodGet, "/", nil), http.StatusNotFound, "Foo"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.data.ReqID(); got != tt.want {
+				t.Errorf("Data.ReqID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Message }} ({{ .ReqID }})"))}
+	w := httptest.NewRecorder()
+	if err := p.Render(w, NewData(r, http.StatusNotFound, "Foo")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), "Foo (666)"; got != want {
+		t.Errorf("Pages.Render() = %q, want %q", got, want)
+	}
+}
+
+func TestData_With(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	d := new(Data)
+	got := d.WithRequest(r).WithStatus(http.StatusNotFound).WithMessage("No such user")
+
+	if got != d {
+		t.Errorf("Data.With*() = %p, want receiver %p", got, d)
+	}
+	if want := (&Data{Req: r, Code: http.StatusNotFound, Msg: "No such user"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Data.With*() = %+v, want %+v", got, want)
+	}
+}
+
+type userData struct {
+	Data
+	UserName string
+}
+
+func TestPages_Render_customFields(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{ .Provider.UserName }}: {{ .Status.Int }} {{ .Message }}`))
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+		msg   string
+		want  string
+	}{
+		{"Plain", &Pages{}, http.StatusNotFound, "Foo", "alice: 404 Foo"},
+		{"Short message", &Pages{MaxMessageLen: 5}, http.StatusNotFound, "Foo", "alice: 404 Foo"},
+		{"Long message", &Pages{MaxMessageLen: 5}, http.StatusNotFound, "Foo bar baz", "alice: 404 Foo b…"},
+		{"Redacted", &Pages{RedactFrom: DefaultRedactFrom}, http.StatusInternalServerError, "secret", "alice: 500 An unexpected error occurred"},
+		{"Default message", &Pages{DefaultMessages: map[Status]string{}}, http.StatusNotFound, "", "alice: 404 Not Found"},
+		{"Zero status", &Pages{DefaultStatus: http.StatusNotFound}, 0, "Foo", "alice: 404 Foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pages.Tmpl = tmpl
+			tt.pages.ErrorLog = log.New(io.Discard, "", 0)
+
+			var buf bytes.Buffer
+			if err := tt.pages.Execute(&buf, &userData{Data{Code: tt.code, Msg: tt.msg}, "alice"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_pageAccessors(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(
+		`{{ .ReqID }}|{{ .Source }}|{{ .Proto }}|{{ .RemoteAddr }}|{{ .IsTLS }}|{{ .Fields.Email }}`,
+	))
+	const want = "abc|users|HTTP/1.1|192.0.2.1:1234|false|help@example.com"
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+	}{
+		{"Plain", &Pages{Tmpl: tmpl}, http.StatusNotFound},
+		{"Lang", &Pages{Tmpl: tmpl, Lang: "nl"}, http.StatusNotFound},
+		{"Server error", &Pages{Tmpl: tmpl}, http.StatusInternalServerError},
+		{"Redacted", &Pages{Tmpl: tmpl, RedactFrom: DefaultRedactFrom}, http.StatusInternalServerError},
+		{"MaxMessageLen", &Pages{Tmpl: tmpl, MaxMessageLen: 2, Lang: "nl"}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{
+				Req:    httptest.NewRequest(http.MethodGet, "/", nil),
+				Code:   tt.code,
+				Msg:    "Oops",
+				ID:     "abc",
+				Src:    "users",
+				Fields: map[string]interface{}{"Email": "help@example.com"},
+			}
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != want {
+				t.Errorf("Pages.Render() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestData_ReqID_unregistered(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), reqIDKey{}, 666))
+
+	if got := NewData(r, http.StatusNotFound, "").ReqID(); got != "" {
+		t.Errorf("Data.ReqID() = %q, want empty", got)
+	}
+}
+
+func TestPages_Render_Err(t *testing.T) {
+	errTmpl := template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }} [{{ .ErrText }}]"))
+
+	tests := []struct {
+		name     string
+		pages    *Pages
+		code     Status
+		wantBody string
+	}{
+		{
+			"Client error",
+			&Pages{Tmpl: errTmpl},
+			http.StatusNotFound,
+			"404 Not here [no such file]",
+		},
+		{
+			"Redacted",
+			&Pages{Tmpl: errTmpl, RedactFrom: DefaultRedactFrom},
+			http.StatusInternalServerError,
+			"500 An unexpected error occurred []",
+		},
+		{
+			"DevMode",
+			&Pages{Tmpl: errTmpl, DevMode: true},
+			http.StatusInternalServerError,
+			"500 Not here [no such file]",
+		},
+		{
+			"Wrapped",
+			&Pages{Tmpl: errTmpl, Lang: "nl", DefaultStatus: http.StatusNotFound},
+			0,
+			"404 Not here [no such file]",
+		},
+		{
+			"Not referenced",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}"))},
+			http.StatusNotFound,
+			"404 Not here",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.pages.ErrorLog = log.New(&buf, "", 0)
+			w := httptest.NewRecorder()
+
+			d := &Data{Code: tt.code, Msg: "Not here", Err: errors.New("no such file")}
+			if err := tt.pages.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.wantBody)
+			}
+			if got := buf.String(); !strings.HasPrefix(got, "no such file; while rendering") {
+				t.Errorf("Pages.Render() log = %q, want the error", got)
+			}
+		})
+	}
+}
+
+func TestPages_Render_DefaultStatus(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(
+		`{{ define "500" }}500 {{ .Message }}{{ end }}{{ define "error" }}{{ .String }}{{ end }}`,
+	))
+
+	tests := []struct {
+		name     string
+		p        *Pages
+		wantCode int
+		want     string
+	}{
+		{
+			"Internal server error",
+			&Pages{Tmpl: tmpl, RedactFrom: -1},
+			http.StatusInternalServerError,
+			"500 Foo bar",
+		},
+		{
+			"DefaultStatus",
+			&Pages{Tmpl: tmpl, DefaultStatus: http.StatusBadRequest},
+			http.StatusBadRequest,
+			"400 Bad Request: Foo bar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.p.Render(w, &Data{Msg: "Foo bar"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_DefaultMessages(t *testing.T) {
+	defaults := map[Status]string{
+		http.StatusNotFound: "The page you requested does not exist",
+	}
+
+	tests := []struct {
+		name     string
+		defaults map[Status]string
+		code     Status
+		msg      string
+		want     string
+	}{
+		{"Configured", defaults, http.StatusNotFound, "", "404 The page you requested does not exist"},
+		{"Status text", defaults, http.StatusGone, "", "410 Gone"},
+		{"Server error", defaults, http.StatusInternalServerError, "", "500 Internal Server Error"},
+		{"Message", defaults, http.StatusNotFound, "No such user", "404 No such user"},
+		{"Not set", nil, http.StatusNotFound, "", "404 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &Pages{
+				Tmpl:            template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}")),
+				DefaultMessages: tt.defaults,
+				ErrorLog:        log.New(&buf, "", 0),
+			}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, &Data{Code: tt.code, Msg: tt.msg}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Pages.Render() log = %q, want empty", buf.String())
+			}
+		})
+	}
+}
+
+func TestPages_Render_StatusText(t *testing.T) {
+	texts := map[Status]string{499: "Client Closed Request", 529: "Site Overloaded"}
+	withTexts := func(p *Pages) *Pages {
+		p.StatusText = texts
+		return p
+	}
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+		want  string
+	}{
+		{
+			"Custom",
+			&Pages{StatusText: texts},
+			529,
+			"<h1>529 Site Overloaded</h1>",
+		},
+		{
+			"Standard",
+			&Pages{StatusText: texts},
+			http.StatusNotFound,
+			"<h1>404 Not Found</h1>",
+		},
+		{
+			"Not set",
+			&Pages{},
+			529,
+			"<h1>529 </h1>",
+		},
+		{
+			"Localized first",
+			&Pages{
+				StatusText: texts,
+				LocalizeStatus: func(lang string, s Status) string {
+					if s == 499 {
+						return "Client a fermé la requête"
+					}
+					return ""
+				},
+			},
+			499,
+			"<h1>499 Client a fermé la requête</h1>",
+		},
+		{
+			"Default message",
+			&Pages{
+				Tmpl:            template.Must(template.New("error").Parse("{{ .StatusText }}: {{ .Message }}")),
+				StatusText:      texts,
+				DefaultMessages: map[Status]string{},
+				RedactFrom:      -1,
+			},
+			529,
+			"Site Overloaded: Site Overloaded",
+		},
+		{
+			"Title",
+			&Pages{StatusText: texts},
+			529,
+			"<title>529 Site Overloaded: </title>",
+		},
+		{
+			"DefaultPages title",
+			withTexts(DefaultPages()),
+			529,
+			"<title>529 Site Overloaded: </title>",
+		},
+		{
+			"StyledPages title",
+			withTexts(StyledPages()),
+			529,
+			"<title>529 Site Overloaded: </title>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			if err := tt.pages.Render(w, &Data{Code: tt.code}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("Pages.Render() = \n%v\nwant containing\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_FallbackTmpl(t *testing.T) {
+	errTmpl := template.Must(template.New("error").Parse("{{ .Missing }}"))
+
+	tests := []struct {
+		name     string
+		fallback *template.Template
+		want     string
+		wantErrs int
+	}{
+		{
+			"No fallback",
+			nil,
+			"500 Internal server error. While handling:\n404 Not Found: Foo bar",
+			1,
+		},
+		{
+			"Fallback",
+			template.Must(template.New("fallback").Parse("<p>Sorry, {{ .Status.Int }}</p>")),
+			"<p>Sorry, 404</p>",
+			1,
+		},
+		{
+			"Fallback error",
+			template.Must(template.New("fallback").Parse("<p>Sorry, {{ .Missing }}</p>")),
+			"500 Internal server error. While handling:\n404 Not Found: Foo bar",
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:         errTmpl,
+				FallbackTmpl: tt.fallback,
+			}
+			w := httptest.NewRecorder()
+
+			err := p.Render(w, &Data{Code: http.StatusNotFound, Msg: "Foo bar"})
+			if err == nil {
+				t.Fatal("Pages.Render() no error")
+			}
+			if n := strings.Count(err.Error(), "can't evaluate field Missing"); n != tt.wantErrs {
+				t.Errorf("Pages.Render() err = %v, want %d errors", err, tt.wantErrs)
+			}
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, http.StatusInternalServerError)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_RenderUsing(t *testing.T) {
+	p := &Pages{
+		Tmpl:         wrongTmpl,
+		StripHeaders: []string{"X-Foo"},
+	}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusNotFound,
+	}
+
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Foo", "Bar")
+
+	if err := p.RenderUsing(w, testTmpl, d); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if got := string(body); got != "404 template" {
+		t.Errorf("Pages.RenderUsing() = %v, want %v", got, "404 template")
+	}
+	if got := resp.Header.Get("X-Foo"); got != "" {
+		t.Errorf("Pages.RenderUsing() header X-Foo = %v, want %v", got, "")
+	}
+}
+
+type headerRecorder struct {
+	*httptest.ResponseRecorder
+	calls int
+}
+
+func (w *headerRecorder) WriteHeader(code int) {
+	w.calls++
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+type softData struct {
+	Data
+	httpStatus Status
+}
+
+func (d *softData) HTTPStatus() Status { return d.httpStatus }
+
+func TestPages_Render_HTTPStatus(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{ define "500" }}500 page{{ end }}{{ define "error" }}error page{{ end }}`))
+
+	tests := []struct {
+		name       string
+		dp         Provider
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			"Status",
+			&Data{Code: http.StatusInternalServerError},
+			http.StatusInternalServerError,
+			"500 page",
+		},
+		{
+			"HTTPStatus",
+			&softData{Data{Code: http.StatusInternalServerError, Msg: "Foo bar"}, http.StatusOK},
+			http.StatusOK,
+			"500 page",
+		},
+		{
+			"Zero HTTPStatus",
+			&softData{Data{Code: http.StatusNotFound}, 0},
+			http.StatusNotFound,
+			"error page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: tmpl}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, tt.dp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPages_Render_DeferHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		deferHeader bool
+		code        Status
+		wantCalls   int
+	}{
+		{
+			"Explicit",
+			false,
+			http.StatusOK,
+			1,
+		},
+		{
+			"Deferred",
+			true,
+			http.StatusOK,
+			0,
+		},
+		{
+			"Error status",
+			true,
+			http.StatusNotFound,
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{DeferHeader: tt.deferHeader}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+			}
+
+			w := &headerRecorder{ResponseRecorder: httptest.NewRecorder()}
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.calls != tt.wantCalls {
+				t.Errorf("Pages.Render() WriteHeader calls = %v, want %v", w.calls, tt.wantCalls)
+			}
+			if w.Code != tt.code.Int() {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.code)
+			}
+		})
+	}
+}
+
+type errorEncoder struct{}
+
+func (errorEncoder) Charset() string               { return "foo" }
+func (errorEncoder) Encode([]byte) ([]byte, error) { return nil, io.ErrUnexpectedEOF }
+
+func TestPages_Render_EncodeError(t *testing.T) {
+	p := &Pages{
+		Encoders: map[Status]Encoder{http.StatusNotFound: errorEncoder{}},
+	}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusNotFound,
+		Msg:  "Foo bar",
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, d); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Pages.Render() error = %v, wantErr %v", err, io.ErrUnexpectedEOF)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Pages.Render() status = %v, want: %v", w.Code, http.StatusInternalServerError)
+	}
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Header() http.Header       { return make(http.Header) }
+func (errorWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+func (errorWriter) WriteHeader(int)           {}
+
+func TestPages_Render_WriteError(t *testing.T) {
+	p := &Pages{}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusTeapot,
+		Msg:  "Foo bar",
+	}
+	if err := p.Render(errorWriter{}, d); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Pages.Render() error = %v, wantErr %v", err, io.ErrClosedPipe)
+	}
+}
+
+const exampleTemplates = `
+{{- define "head" -}}
+<head>
+	<meta charset="utf-8">
+	<title>{{ .String }}</title>
+</head>
+{{- end -}}
+
+{{- define "error" -}}
+<!DOCTYPE html>
+<html lang="en">
+{{ template "head" . }}
+<body>
+	<h1>{{ .Status.Int }} {{ .Status }}</h1>
+	<p>
+		{{ .Message }} while serving {{ .Request.URL.Path }}.
+		Request ID: {{ .Provider.ReqID }}
+	</p>
+	<p><i>This is a generic error page</i><p>
+</body>
+</html>
+{{- end -}}
+
+{{- define "500" -}}
+<!DOCTYPE html>
+<html lang="en">
+{{ template "head" . }}
+<body>
+	<h1>Snap!</h1>
+	<h2>{{ .Status.Int }} {{ .Status }}</h2>
+	<p>
+		Something went really wrong and we've been notified!
+		Please try again later.
+	</p>
+	<p><i>
+		Error: {{ .Message }} while serving {{ .Request.URL.Path }}.
+		Request ID: {{ .Provider.ReqID }}
+	</i></p>
+</body>
+</html>
+{{- end -}}
+
+{{- define "404" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+	{{ template "head" . }}
+</head>
+<body>
+	<h1>{{ .Status.Int}} {{ .Status }}</h1>
+	<p>
+		{{ .Request.URL.Path }} could not be found.
+	</p>
+</body>
+</html>
+{{- end -}}`
+
+func Example() {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse(exampleTemplates))}
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	w := httptest.NewRecorder()
+
+	// Extend Data, as needed
+	type data struct {
+		Data
+		ReqID int
+	}
+
+	// Serves the client with the "500" template
+	err := p.Render(w, &data{Data{Req: req, Code: http.StatusInternalServerError, Msg: "DB connection"}, 666})
+	if err != nil {
+		log.Println(err)
 	}
 
 	resp := w.Result()
@@ -395,7 +2071,7 @@ func Example() {
 	w = httptest.NewRecorder()
 
 	// 400 is not defined, so the generic "error" template is used instead.
-	err = p.Render(w, &data{Data{req, http.StatusBadRequest, "Missing token in URL"}, 667})
+	err = p.Render(w, &data{Data{Req: req, Code: http.StatusBadRequest, Msg: "Missing token in URL"}, 667})
 	if err != nil {
 		log.Println(err)
 	}
@@ -405,10 +2081,47 @@ func Example() {
 
 	fmt.Println(resp.StatusCode)
 	fmt.Println(string(body))
+	// Output:
+	// 500
+	// <!DOCTYPE html>
+	// <html lang="en">
+	// <head>
+	// 	<meta charset="utf-8">
+	// 	<title>500 Internal Server Error: An unexpected error occurred</title>
+	// </head>
+	// <body>
+	// 	<h1>Snap!</h1>
+	// 	<h2>500 Internal Server Error</h2>
+	// 	<p>
+	// 		Something went really wrong and we've been notified!
+	// 		Please try again later.
+	// 	</p>
+	// 	<p><i>
+	// 		Error: An unexpected error occurred while serving /foo.
+	// 		Request ID: 666
+	// 	</i></p>
+	// </body>
+	// </html>
+	// 400
+	// <!DOCTYPE html>
+	// <html lang="en">
+	// <head>
+	// 	<meta charset="utf-8">
+	// 	<title>400 Bad Request: Missing token in URL</title>
+	// </head>
+	// <body>
+	// 	<h1>400 Bad Request</h1>
+	// 	<p>
+	// 		Missing token in URL while serving /foo.
+	// 		Request ID: 667
+	// 	</p>
+	// 	<p><i>This is a generic error page</i><p>
+	// </body>
+	// </html>
 }
 
 func Example_notFoundHandler() {
-	p := &Pages{template.Must(template.New("error").Parse(exampleTemplates))}
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse(exampleTemplates))}
 
 	rtr := mux.NewRouter()
 	rtr.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -417,3 +2130,338 @@ func Example_notFoundHandler() {
 		}
 	})
 }
+
+func ExamplePages_Execute() {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse(`{{ define "404" }}<h1>{{ .Status }}</h1>{{ end }}`))}
+
+	// page can be written to a file, for a CDN.
+	var page bytes.Buffer
+	if err := p.Execute(&page, &Data{Code: http.StatusNotFound}); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(page.String())
+	// Output: <h1>Not Found</h1>
+}
+
+func TestPages_TemplateFor(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`
+		{{- define "404" }}{{ end -}}
+		{{- define "410.html" }}{{ end -}}
+		{{- define "410" }}{{ end -}}
+		{{- define "timeout" }}{{ end -}}
+		{{- define "5xx" }}{{ end -}}
+	`))
+
+	tests := []struct {
+		name   string
+		pages  *Pages
+		status Status
+		want   string
+	}{
+		{"Exact", &Pages{Tmpl: tmpl}, http.StatusNotFound, "404"},
+		{"Format", &Pages{Tmpl: tmpl}, http.StatusGone, "410.html"},
+		{"DisableNegotiation", &Pages{Tmpl: tmpl, DisableNegotiation: true}, http.StatusGone, "410"},
+		{"Timeout", &Pages{Tmpl: tmpl}, http.StatusGatewayTimeout, "timeout"},
+		{"Class", &Pages{Tmpl: tmpl}, http.StatusBadGateway, "5xx"},
+		{"Generic", &Pages{Tmpl: tmpl}, http.StatusTeapot, "error"},
+		{"Default", &Pages{}, http.StatusNotFound, DefaultTmplName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, got := tt.pages.TemplateFor(tt.status)
+			if name != tt.want {
+				t.Errorf("Pages.TemplateFor() name = %v, want %v", name, tt.want)
+			}
+			if got == nil {
+				t.Fatal("Pages.TemplateFor() returned nil template")
+			}
+			if tt.want != DefaultTmplName && got.Name() != tt.want {
+				t.Errorf("Pages.TemplateFor() template = %v, want %v", got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultFuncs(t *testing.T) {
+	RegisterStatusText(598, "Network Read Timeout")
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		want  string
+	}{
+		{"NewDefaultTmpl", &Pages{Tmpl: NewDefaultTmpl(nil)}, "Not Found, Network Read Timeout, "},
+		{"Funcs", (&Pages{Tmpl: template.New("error")}).Funcs(DefaultFuncs()), "Not Found, Network Read Timeout, "},
+		{"Nil Tmpl", (&Pages{}).Funcs(nil), "Not Found, Network Read Timeout, "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template.Must(tt.pages.Tmpl.New("410").Parse(`{{ statusText 404 }}, {{ statusText 598 }}, {{ statusText 999 }}`))
+
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, &Data{Code: http.StatusGone}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Funcs(t *testing.T) {
+	funcs := template.FuncMap{
+		"humanize": func(s Status) string { return strings.ToLower(s.String()) },
+	}
+	const page = `{{ humanize .Status }}`
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		parse func(p *Pages) (*template.Template, error)
+		want  string
+	}{
+		{
+			"Nil Tmpl",
+			&Pages{},
+			func(p *Pages) (*template.Template, error) { return p.Tmpl.New("500").Parse(page) },
+			"<h1>404 Not Found</h1>",
+		},
+		{
+			"Tmpl",
+			&Pages{Tmpl: template.New("error")},
+			func(p *Pages) (*template.Template, error) { return p.Tmpl.Parse(page) },
+			"not found",
+		},
+		{
+			"Locales",
+			&Pages{Locales: map[string]*template.Template{
+				"nl": template.New("error"),
+				"fr": nil,
+			}},
+			func(p *Pages) (*template.Template, error) { return p.Locales["nl"].Parse(page) },
+			"not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.pages.Funcs(funcs)
+			if p != tt.pages {
+				t.Fatal("Pages.Funcs() did not return p")
+			}
+			template.Must(tt.parse(p))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", "nl")
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_executeDefault(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(dp Provider) interface{}
+		dp   Provider
+	}{
+		{
+			"Provider",
+			func(dp Provider) interface{} { return dp },
+			&Data{Code: http.StatusNotFound, Msg: "Foo bar"},
+		},
+		{
+			"Page",
+			func(dp Provider) interface{} {
+				pg := newPage(dp)
+				pg.Lang = `nl"<>`
+				return pg
+			},
+			&Data{Code: http.StatusNotFound, Msg: "Foo bar"},
+		},
+		{
+			"Escaping",
+			func(dp Provider) interface{} { return dp },
+			&Data{Code: http.StatusBadRequest, Msg: "<script>alert('1+1' & \"x\")</script>\x00"},
+		},
+		{
+			"Unicode",
+			func(dp Provider) interface{} { return dp },
+			&Data{Code: http.StatusTeapot, Msg: "Ünïcödé ✓ \xff"},
+		},
+		{
+			"Unknown status",
+			func(dp Provider) interface{} { return dp },
+			&Data{Code: 999},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data(tt.dp)
+
+			pg, ok := data.(*page)
+			if !ok {
+				pg = newPage(tt.dp)
+			}
+			var want bytes.Buffer
+			if err := defTmpl.Execute(&want, pg); err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+			if err := executeDefault(&got, tt.dp, data); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != want.String() {
+				t.Errorf("executeDefault() =\n%s\nwant\n%s", got.String(), want.String())
+			}
+		})
+	}
+}
+
+func Test_executeDefault_error(t *testing.T) {
+	err := executeDefault(&limitWriter{new(bytes.Buffer), 10}, &Data{Code: http.StatusNotFound}, nil)
+	if !errors.Is(err, ErrMaxBufferBytes) {
+		t.Errorf("executeDefault() err = %v, want %v", err, ErrMaxBufferBytes)
+	}
+}
+
+func Test_bufPool_Put(t *testing.T) {
+	tests := []struct {
+		name string
+		max  int
+		size int
+		want bool
+	}{
+		{"Small", 1 << 10, 100, true},
+		{"Large", 1 << 10, 10 << 10, false},
+		{"No limit", 0, 10 << 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(max int) { MaxPooledBufferBytes = max }(MaxPooledBufferBytes)
+			MaxPooledBufferBytes = tt.max
+
+			var (
+				p = &bufPool{}
+				b = p.Get()
+			)
+			b.Write(make([]byte, tt.size))
+			p.Put(b)
+
+			// sync.Pool may drop any item, so only a pooled buffer proves anything.
+			if got := p.Get(); got == b && !tt.want {
+				t.Errorf("bufPool.Put() pooled buffer of capacity %d, want discarded", b.Cap())
+			} else if got == b && got.Len() != 0 {
+				t.Errorf("bufPool.Put() pooled buffer of length %d, want reset", got.Len())
+			}
+		})
+	}
+}
+
+// discardWriter is a http.ResponseWriter which discards everything,
+// so benchmarks only measure Render.
+type discardWriter struct {
+	h http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.h }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func BenchmarkPages_Render_default(b *testing.B) {
+	p := new(Pages)
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: http.StatusNotFound,
+		Msg:  "Foo bar",
+	}
+	w := &discardWriter{h: make(http.Header)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := p.Render(w, d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPages_Render_bufPool renders mostly small pages and every 100th a large one.
+// With MaxPooledBufferBytes, the large buffers are discarded instead of pinned in the pool,
+// which costs an allocation for the occasional large page.
+func BenchmarkPages_Render_bufPool(b *testing.B) {
+	tmpl := template.Must(template.New("error").Parse("{{ .Message }}"))
+	small := &Data{Code: http.StatusNotFound, Msg: "Foo bar"}
+	large := &Data{Code: http.StatusNotFound, Msg: strings.Repeat("Lorem ipsum dolor sit amet. ", 10<<10)}
+
+	for _, max := range []int{MaxPooledBufferBytes, 0} {
+		b.Run(fmt.Sprintf("max=%d", max), func(b *testing.B) {
+			defer func(max int) { MaxPooledBufferBytes = max }(MaxPooledBufferBytes)
+			MaxPooledBufferBytes = max
+
+			p := &Pages{Tmpl: tmpl, DisableCompression: true}
+			w := &discardWriter{h: make(http.Header)}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				d := small
+				if i%100 == 0 {
+					d = large
+				}
+				if err := p.Render(w, d); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// The race detector, as used in CI, catches unsynchronized swaps.
+func TestPages_SetTemplate(t *testing.T) {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse("Tmpl"))}
+
+	sets := []*template.Template{
+		template.Must(template.New("error").Parse("One")),
+		template.Must(template.New("error").Parse("Two")),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			p.SetTemplate(sets[i%2])
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Body.String(); got != "Tmpl" && got != "One" && got != "Two" {
+			t.Fatalf("Pages.Render() = %q", got)
+		}
+	}
+	<-done
+
+	p.SetTemplate(nil)
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); got != "Tmpl" {
+		t.Errorf("Pages.Render() after SetTemplate(nil) = %q, want %q", got, "Tmpl")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+/*
+Package ehtmlcharset provides ehtml.Encoder implementations based on golang.org/x/text/encoding.
+It is kept separate so that the ehtml package itself does not import the encoding tables,
+which add considerably to the binary size. ehtml only uses golang.org/x/text/language.
+
+	p := &ehtml.Pages{
+		Tmpl: tmpl,
+		Encoders: map[ehtml.Status]ehtml.Encoder{
+			http.StatusNotFound: ehtmlcharset.MustLookup("iso-8859-1"),
+		},
+	}
+*/
+package ehtmlcharset
+
+import (
+	"fmt"
+
+	"github.com/moapis/ehtml"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type encoder struct {
+	charset string
+	enc     encoding.Encoding
+}
+
+// New returns an Encoder for enc, announced as charset in the Content-Type header.
+// Characters which can't be represented in the target encoding
+// are written as HTML numeric character references.
+func New(charset string, enc encoding.Encoding) ehtml.Encoder {
+	return &encoder{charset, enc}
+}
+
+// Lookup returns an Encoder for the encoding by name,
+// as defined by the WHATWG Encoding Standard. For example: "iso-8859-1" or "shift_jis".
+func Lookup(name string) (ehtml.Encoder, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("ehtmlcharset Lookup %q: %w", name, err)
+	}
+
+	charset, err := htmlindex.Name(enc)
+	if err != nil {
+		return nil, fmt.Errorf("ehtmlcharset Lookup %q: %w", name, err)
+	}
+
+	return New(charset, enc), nil
+}
+
+// MustLookup is like Lookup, but panics on error.
+func MustLookup(name string) ehtml.Encoder {
+	e, err := Lookup(name)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func (e *encoder) Charset() string { return e.charset }
+
+func (e *encoder) Encode(page []byte) ([]byte, error) {
+	return encoding.HTMLEscapeUnsupported(e.enc.NewEncoder()).Bytes(page)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtmlcharset
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moapis/ehtml"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		want      string
+		wantErr   bool
+		wantPanic bool
+	}{
+		{"latin1", "windows-1252", false, false},
+		{"Shift_JIS", "shift_jis", false, false},
+		{"foo", "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Lookup(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && e.Charset() != tt.want {
+				t.Errorf("Lookup().Charset() = %v, want %v", e.Charset(), tt.want)
+			}
+
+			defer func() {
+				if got := recover() != nil; got != tt.wantPanic {
+					t.Errorf("MustLookup() panic = %v, want %v", got, tt.wantPanic)
+				}
+			}()
+			MustLookup(tt.name)
+		})
+	}
+}
+
+func TestEncoder_Render(t *testing.T) {
+	p := &ehtml.Pages{
+		Tmpl: template.Must(template.New("error").Parse(`{{ .Message }}`)),
+		Encoders: map[ehtml.Status]ehtml.Encoder{
+			http.StatusNotFound: MustLookup("iso-8859-1"),
+		},
+	}
+
+	tests := []struct {
+		name            string
+		code            ehtml.Status
+		want            string
+		wantContentType string
+	}{
+		{
+			"Encoded",
+			http.StatusNotFound,
+			"Caf\xe9 &#9731;",
+			"text/html; charset=windows-1252",
+		},
+		{
+			"UTF-8",
+			http.StatusBadRequest,
+			"Café ☃",
+			"text/html; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &ehtml.Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+				Msg:  "Café ☃",
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Pages.Render() Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+/*
+Package ehtmlgrpcweb renders ehtml errors for gRPC-web clients.
+Instead of a html page, the status and message of the Provider
+are encoded into a gRPC-web trailer frame.
+*/
+package ehtmlgrpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/moapis/ehtml"
+)
+
+// Code is a gRPC status code.
+type Code uint32
+
+// gRPC status codes, as defined by https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	OK Code = iota
+	Canceled
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+var codes = map[ehtml.Status]Code{
+	http.StatusBadRequest:          InvalidArgument,
+	http.StatusUnauthorized:        Unauthenticated,
+	http.StatusForbidden:           PermissionDenied,
+	http.StatusNotFound:            NotFound,
+	http.StatusRequestTimeout:      DeadlineExceeded,
+	http.StatusConflict:            AlreadyExists,
+	http.StatusPreconditionFailed:  FailedPrecondition,
+	http.StatusTooManyRequests:     ResourceExhausted,
+	499:                            Canceled, // Client Closed Request
+	http.StatusInternalServerError: Internal,
+	http.StatusNotImplemented:      Unimplemented,
+	http.StatusBadGateway:          Unavailable,
+	http.StatusServiceUnavailable:  Unavailable,
+	http.StatusGatewayTimeout:      DeadlineExceeded,
+}
+
+// CodeFor maps a HTTP status to a gRPC code.
+// Successful statuses map to OK, unmapped client errors to FailedPrecondition
+// and all others to Unknown.
+func CodeFor(s ehtml.Status) Code {
+	if c, ok := codes[s]; ok {
+		return c
+	}
+
+	switch {
+	case s >= 200 && s < 300:
+		return OK
+	case s >= 400 && s < 500:
+		return FailedPrecondition
+	default:
+		return Unknown
+	}
+}
+
+// trailerFlag marks a gRPC-web frame as trailers.
+const trailerFlag = 0x80
+
+// Frame returns the gRPC-web trailer frame for dp,
+// containing the grpc-status and grpc-message trailers.
+func Frame(dp ehtml.Provider) []byte {
+	var trailers bytes.Buffer
+	fmt.Fprintf(&trailers, "grpc-status: %d\r\n", CodeFor(dp.Status()))
+	if msg := dp.Message(); msg != "" {
+		fmt.Fprintf(&trailers, "grpc-message: %s\r\n", encodeMessage(msg))
+	}
+
+	frame := make([]byte, 5, 5+trailers.Len())
+	frame[0] = trailerFlag
+	binary.BigEndian.PutUint32(frame[1:], uint32(trailers.Len()))
+
+	return append(frame, trailers.Bytes()...)
+}
+
+// Render writes the trailer frame for dp to w, as the only frame of a gRPC-web response.
+// dp is prepared by p first, see ehtml.Pages.Prepare,
+// so the message is redacted, truncated or defaulted as it would be on a page.
+// The HTTP status is always 200, as required by gRPC.
+// If the request was made in the "application/grpc-web-text" format,
+// the frame is base64 encoded.
+func Render(p *ehtml.Pages, w http.ResponseWriter, dp ehtml.Provider) error {
+	dp = p.Prepare(dp)
+	frame := Frame(dp)
+
+	contentType := "application/grpc-web+proto"
+	if r := dp.Request(); r != nil && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc-web-text") {
+		contentType = "application/grpc-web-text"
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(frame)))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(frame); err != nil {
+		return fmt.Errorf("ehtmlgrpcweb Render, write to client: %w", err)
+	}
+	return nil
+}
+
+// encodeMessage percent-encodes msg as required for the grpc-message trailer.
+func encodeMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtmlgrpcweb
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moapis/ehtml"
+)
+
+func TestCodeFor(t *testing.T) {
+	tests := []struct {
+		status ehtml.Status
+		want   Code
+	}{
+		{http.StatusOK, OK},
+		{http.StatusNotFound, NotFound},
+		{http.StatusTeapot, FailedPrecondition},
+		{http.StatusGatewayTimeout, DeadlineExceeded},
+		{http.StatusInsufficientStorage, Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status.String(), func(t *testing.T) {
+			if got := CodeFor(tt.status); got != tt.want {
+				t.Errorf("CodeFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			"Message",
+			"Foo 100%",
+			"\x80\x00\x00\x00\x2agrpc-status: 5\r\ngrpc-message: Foo 100%25\r\n",
+		},
+		{
+			"No message",
+			"",
+			"\x80\x00\x00\x00\x10grpc-status: 5\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &ehtml.Data{Code: http.StatusNotFound, Msg: tt.msg}
+			if got := string(Frame(d)); got != tt.want {
+				t.Errorf("Frame() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		wantContentType string
+		decode          func(string) string
+	}{
+		{
+			"Binary",
+			"application/grpc-web+proto",
+			"application/grpc-web+proto",
+			func(s string) string { return s },
+		},
+		{
+			"Text",
+			"application/grpc-web-text+proto",
+			"application/grpc-web-text",
+			func(s string) string {
+				b, _ := base64.StdEncoding.DecodeString(s)
+				return string(b)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "http://example.com/foo.Bar/Baz", nil)
+			r.Header.Set("Content-Type", tt.contentType)
+			d := &ehtml.Data{Req: r, Code: http.StatusNotFound, Msg: "Foo"}
+
+			w := httptest.NewRecorder()
+			if err := Render(&ehtml.Pages{}, w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Render() status = %v, want %v", resp.StatusCode, http.StatusOK)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Render() Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+			if got, want := tt.decode(string(body)), string(Frame(d)); got != want {
+				t.Errorf("Render() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+type errorWriter struct{ h http.Header }
+
+func (w errorWriter) Header() http.Header     { return w.h }
+func (errorWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+func (errorWriter) WriteHeader(int)           {}
+
+func TestRender_WriteError(t *testing.T) {
+	d := &ehtml.Data{Code: http.StatusNotFound}
+	if err := Render(&ehtml.Pages{}, errorWriter{make(http.Header)}, d); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Render() error = %v, wantErr %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestRender_Pages(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages *ehtml.Pages
+		code  ehtml.Status
+		msg   string
+		want  *ehtml.Data
+	}{
+		{
+			"Redacted",
+			&ehtml.Pages{RedactFrom: ehtml.DefaultRedactFrom, ErrorLog: log.New(io.Discard, "", 0)},
+			http.StatusInternalServerError,
+			"pq: connection refused",
+			&ehtml.Data{Code: http.StatusInternalServerError, Msg: ehtml.DefaultRedactedMessage},
+		},
+		{
+			"Truncated",
+			&ehtml.Pages{MaxMessageLen: 3},
+			http.StatusNotFound,
+			"Foo bar",
+			&ehtml.Data{Code: http.StatusNotFound, Msg: "Foo…"},
+		},
+		{
+			"Default status",
+			&ehtml.Pages{DefaultStatus: http.StatusConflict},
+			0,
+			"Foo",
+			&ehtml.Data{Code: http.StatusConflict, Msg: "Foo"},
+		},
+		{
+			"Transform",
+			&ehtml.Pages{Transform: func(r *http.Request, dp ehtml.Provider) ehtml.Provider {
+				return &ehtml.Data{Req: r, Code: http.StatusNotFound, Msg: "Not here"}
+			}},
+			http.StatusForbidden,
+			"Foo",
+			&ehtml.Data{Code: http.StatusNotFound, Msg: "Not here"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := Render(tt.pages, w, &ehtml.Data{Code: tt.code, Msg: tt.msg}); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := w.Body.String(), string(Frame(tt.want)); got != want {
+				t.Errorf("Render() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+)
+
+// StatusForError returns the status to render for err.
+// Timeouts, such as context.DeadlineExceeded or a net.Error reporting Timeout(),
+// result in 504 Gateway Timeout. All other errors result in 500 Internal Server Error.
+func StatusForError(err error) Status {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RenderErr renders the page for err, with r as request, like Handle without status and message.
+// The status is determined by StatusMapper, if set, or StatusForError,
+// which results in 500 Internal Server Error for unmapped errors.
+// The message of err is used as message, so 5xx messages are redacted by default, see RedactFrom.
+// err is set as Data.Err and logged to ErrorLog.
+func (p *Pages) RenderErr(w http.ResponseWriter, r *http.Request, err error) error {
+	return p.Handle(w, r, 0, "", err)
+}
+
+// statusForError returns the status for err from StatusMapper or StatusForError.
+func (p *Pages) statusForError(err error) Status {
+	if p.StatusMapper != nil {
+		if s := p.StatusMapper(err); s != 0 {
+			return s
+		}
+	}
+	return StatusForError(err)
+}
+
+// Errors returned by the Render methods, which can be tested for with errors.Is.
+// The returned errors still wrap their cause, which errors.Is and errors.As find as well.
+var (
+	// ErrTemplateExec is returned when producing the page failed,
+	// such as executing the template, encoding or compressing it.
+	// RenderError, or the page from FallbackTmpl, was sent to the client instead.
+	ErrTemplateExec = errors.New("ehtml: template execution failed")
+
+	// ErrClientWrite is returned when writing the page to the client failed,
+	// for example because the connection was closed.
+	// The page may have been partially written.
+	ErrClientWrite = errors.New("ehtml: write to client failed")
+
+	// ErrHeadersSent is returned when the status was already written to the ResponseWriter,
+	// as reported by a HeaderWriter. Nothing is written, to not corrupt the response.
+	ErrHeadersSent = errors.New("ehtml: headers already sent")
+)
+
+// kindError is an error matching kind, without adding it to the message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// wrapKind returns err, matching kind for errors.Is.
+func wrapKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
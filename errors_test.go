@@ -0,0 +1,214 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Status
+	}{
+		{
+			"Context deadline",
+			fmt.Errorf("query: %w", context.DeadlineExceeded),
+			http.StatusGatewayTimeout,
+		},
+		{
+			"OS deadline",
+			os.ErrDeadlineExceeded,
+			http.StatusGatewayTimeout,
+		},
+		{
+			"Net timeout",
+			&net.DNSError{IsTimeout: true},
+			http.StatusGatewayTimeout,
+		},
+		{
+			"Net other",
+			&net.DNSError{},
+			http.StatusInternalServerError,
+		},
+		{
+			"Other",
+			errors.New("foo"),
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusForError(tt.err); got != tt.want {
+				t.Errorf("StatusForError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_errorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		pages     *Pages
+		accept    string
+		w         http.ResponseWriter
+		wantKind  error
+		otherKind error
+		wantCause error
+		wantMsg   string
+	}{
+		{
+			"Template",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Missing }}"))},
+			"",
+			httptest.NewRecorder(),
+			ErrTemplateExec,
+			ErrClientWrite,
+			nil,
+			"ehtml Render template: ",
+		},
+		{
+			"MaxBufferBytes",
+			&Pages{MaxBufferBytes: 10},
+			"",
+			httptest.NewRecorder(),
+			ErrTemplateExec,
+			ErrClientWrite,
+			ErrMaxBufferBytes,
+			"ehtml Render template: ",
+		},
+		{
+			"JSON MaxBufferBytes",
+			&Pages{MaxBufferBytes: 10},
+			"application/json",
+			httptest.NewRecorder(),
+			ErrTemplateExec,
+			ErrClientWrite,
+			ErrMaxBufferBytes,
+			"ehtml Render json: ",
+		},
+		{
+			"Encoder",
+			&Pages{Encoders: map[Status]Encoder{http.StatusNotFound: errorEncoder{}}},
+			"",
+			httptest.NewRecorder(),
+			ErrTemplateExec,
+			ErrClientWrite,
+			io.ErrUnexpectedEOF,
+			"ehtml Render encode foo: ",
+		},
+		{
+			"Client write",
+			&Pages{},
+			"",
+			errorWriter{},
+			ErrClientWrite,
+			ErrTemplateExec,
+			io.ErrClosedPipe,
+			"ehtml Render, write to client: io: read/write on closed pipe",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{Code: http.StatusNotFound, Msg: "Foo bar"}
+			if tt.accept != "" {
+				d.Req = httptest.NewRequest("GET", "http://example.com/foo", nil)
+				d.Req.Header.Set("Accept", tt.accept)
+			}
+			err := tt.pages.Render(tt.w, d)
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("Pages.Render() err = %v, want %v", err, tt.wantKind)
+			}
+			if errors.Is(err, tt.otherKind) {
+				t.Errorf("Pages.Render() err = %v, don't want %v", err, tt.otherKind)
+			}
+			if tt.wantCause != nil && !errors.Is(err, tt.wantCause) {
+				t.Errorf("Pages.Render() err = %v, want cause %v", err, tt.wantCause)
+			}
+			if !strings.HasPrefix(err.Error(), tt.wantMsg) {
+				t.Errorf("Pages.Render() err = %q, want prefix %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestPages_RenderErr(t *testing.T) {
+	errNoRows := errors.New("sql: no rows in result set")
+
+	mapper := func(err error) Status {
+		if errors.Is(err, errNoRows) {
+			return http.StatusNotFound
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name     string
+		mapper   func(error) Status
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			"Mapped",
+			mapper,
+			fmt.Errorf("get user: %w", errNoRows),
+			http.StatusNotFound,
+			"404 get user: sql: no rows in result set",
+		},
+		{
+			"Unmapped",
+			mapper,
+			errors.New("foo"),
+			http.StatusInternalServerError,
+			"500 An unexpected error occurred",
+		},
+		{
+			"Unmapped timeout",
+			mapper,
+			context.DeadlineExceeded,
+			http.StatusGatewayTimeout,
+			"504 An unexpected error occurred",
+		},
+		{
+			"No mapper",
+			nil,
+			errNoRows,
+			http.StatusInternalServerError,
+			"500 An unexpected error occurred",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:         template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}")),
+				StatusMapper: tt.mapper,
+			}
+			w := httptest.NewRecorder()
+
+			if err := p.RenderErr(w, httptest.NewRequest(http.MethodGet, "/users/1", nil), tt.err); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.RenderErr() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.RenderErr() = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
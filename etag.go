@@ -0,0 +1,233 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"html/template"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// fingerprints caches the hashes of template sources, keyed by template set.
+type fingerprints struct {
+	mu sync.Mutex
+	m  map[*template.Template][]byte
+}
+
+// namedTree is a template of a set, as input for a fingerprint.
+type namedTree struct {
+	name string
+	tree *parse.Tree
+}
+
+func hashTrees(trees []namedTree) []byte {
+	sort.Slice(trees, func(i, j int) bool { return trees[i].name < trees[j].name })
+
+	h := sha256.New()
+	for _, t := range trees {
+		io.WriteString(h, t.name)
+		h.Write([]byte{0})
+		if t.tree != nil && t.tree.Root != nil {
+			io.WriteString(h, t.tree.Root.String())
+		}
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
+
+// fingerprint returns the hash of the sources of the templates in set.
+// It is computed once for Tmpl and the sets of Locales, before they are first executed by p,
+// as execution modifies the parse trees of html/template.
+// Other sets, such as passed to RenderUsing, are not cached.
+func (p *Pages) fingerprint(set *template.Template) []byte {
+	if set == nil {
+		return nil
+	}
+	if !p.ownSet(set) {
+		return hashTrees(htmlTrees(set))
+	}
+
+	p.fingerprints.mu.Lock()
+	defer p.fingerprints.mu.Unlock()
+
+	if fp, ok := p.fingerprints.m[set]; ok {
+		return fp
+	}
+
+	fp := hashTrees(htmlTrees(set))
+
+	if p.fingerprints.m == nil {
+		p.fingerprints.m = make(map[*template.Template][]byte)
+	}
+	p.fingerprints.m[set] = fp
+	return fp
+}
+
+func (p *Pages) ownSet(set *template.Template) bool {
+	if set == p.Tmpl || set == p.swapped.Load() || (p.watch != nil && set == p.watch.current()) {
+		return true
+	}
+	for _, l := range p.Locales {
+		if set == l {
+			return true
+		}
+	}
+	return false
+}
+
+func htmlTrees(set *template.Template) []namedTree {
+	var trees []namedTree
+	for _, t := range set.Templates() {
+		trees = append(trees, namedTree{t.Name(), t.Tree})
+	}
+	return trees
+}
+
+// textFingerprint is like fingerprint, for text templates.
+func (p *Pages) textFingerprint(set *texttemplate.Template) []byte {
+	if set == nil {
+		return nil
+	}
+
+	var trees []namedTree
+	for _, t := range set.Templates() {
+		trees = append(trees, namedTree{t.Name(), t.Tree})
+	}
+	return hashTrees(trees)
+}
+
+func writeField(h hash.Hash, s string) {
+	io.WriteString(h, s)
+	h.Write([]byte{0})
+}
+
+// funcName returns the name of the func fn, or the empty string if it is nil.
+// Closures are named after their enclosing func, eg: "main.main.func1".
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// stableETag returns a weak ETag derived from the template sources
+// and the request independent values of dp, see StableETag.
+func (p *Pages) stableETag(set *template.Template, format string, enc Encoder, lang string, dp Provider) string {
+	h := sha256.New()
+
+	h.Write(p.fingerprint(set))
+	if format != formatHTML {
+		h.Write(p.textFingerprint(p.TextTmpl))
+	}
+	writeField(h, DefaultTmpl)
+	writeField(h, registeredDefaultSource(dp.Status()))
+
+	writeField(h, format)
+	if format == formatHTML {
+		// The template named by a TemplateNamer may differ from the one for the status.
+		writeField(h, tmplName(p.lookupPage(set, dp)))
+	}
+	if enc != nil {
+		writeField(h, enc.Charset())
+	}
+	writeField(h, p.Charset)
+	writeField(h, p.Layout)
+	writeField(h, funcName(p.Sanitizer))
+	writeField(h, strconv.Itoa(dp.Status().Int()))
+	writeField(h, dp.String())
+
+	// The values the templates get on top of the Provider.
+	pg := p.templateData(dp, lang, "").(*page)
+	writeField(h, pg.Lang)
+	writeField(h, pg.StatusText())
+	writeField(h, pg.BasePath)
+	writeField(h, pg.ErrText())
+	writeField(h, pg.stack)
+	if pg.RateLimit != nil {
+		fmt.Fprintf(h, "%d %d %d\x00", pg.RateLimit.Limit, pg.RateLimit.Remaining, pg.RateLimit.Reset.Unix())
+	}
+	if pg.BlockedBy != nil {
+		writeField(h, pg.BlockedBy.Authority)
+		writeField(h, pg.BlockedBy.Ref)
+	}
+
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag,
+// using the weak comparison.
+func etagMatch(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(v), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag header if StableETag is enabled
+// and reports whether the request's If-None-Match header matches it.
+func (p *Pages) notModified(h http.Header, set *template.Template, format string, enc Encoder, lang string, dp Provider) bool {
+	if !p.StableETag || p.usesNonce() {
+		return false
+	}
+
+	etag := p.stableETag(set, format, enc, lang, dp)
+	h.Set("ETag", etag)
+
+	return requestMatches(dp.Request(), etag)
+}
+
+// bodyETag returns a weak ETag from the hash of a rendered body.
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// bodyNotModified sets the ETag header from the hash of body if EnableETag is enabled,
+// and reports whether the request's If-None-Match header matches it.
+// The ETag from StableETag takes precedence.
+func (p *Pages) bodyNotModified(h http.Header, dp Provider, body []byte) bool {
+	if !p.EnableETag || p.StableETag {
+		return false
+	}
+
+	etag := bodyETag(body)
+	h.Set("ETag", etag)
+
+	return requestMatches(dp.Request(), etag)
+}
+
+// requestMatches reports whether r is a GET or HEAD request
+// with an If-None-Match header matching etag.
+func requestMatches(r *http.Request, etag string) bool {
+	if r == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	inm := r.Header.Get("If-None-Match")
+	return inm != "" && etagMatch(inm, etag)
+}
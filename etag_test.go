@@ -0,0 +1,299 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_etagMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"Any", "*", true},
+		{"Weak", `W/"foo"`, true},
+		{"Strong", `"foo"`, true},
+		{"List", `"bar", W/"foo"`, true},
+		{"Mismatch", `"bar"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatch(tt.ifNoneMatch, `W/"foo"`); got != tt.want {
+				t.Errorf("etagMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const etagTemplate = `{{ define "header" }}<h1>{{ .Status }}</h1>{{ end }}` +
+	`{{ define "error" }}{{ template "header" . }}{{ .Message }} {{ .Request.URL.Path }}{{ end }}`
+
+// etagOf renders the page for a fresh Pages,
+// as if the server was restarted, and returns the ETag.
+func etagOf(t *testing.T, path string, code Status, msg string) string {
+	t.Helper()
+
+	p := &Pages{
+		Tmpl:       template.Must(template.New("error").Parse(etagTemplate)),
+		StableETag: true,
+	}
+
+	// Execute once, as a prior request would have.
+	p.Render(httptest.NewRecorder(), &Data{Req: httptest.NewRequest("GET", "http://example.com/", nil), Code: http.StatusTeapot})
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{Req: httptest.NewRequest("GET", "http://example.com"+path, nil), Code: code, Msg: msg}); err != nil {
+		t.Fatal(err)
+	}
+	return w.Header().Get("ETag")
+}
+
+func TestPages_Render_StableETag(t *testing.T) {
+	p := &Pages{
+		Tmpl:       template.Must(template.New("error").Parse(etagTemplate)),
+		StableETag: true,
+	}
+	etag := etagOf(t, "/foo", http.StatusNotFound, "Foo bar")
+
+	if etag == "" {
+		t.Fatal("Pages.Render() no ETag")
+	}
+	if got := etagOf(t, "/bar", http.StatusNotFound, "Foo bar"); got != etag {
+		t.Errorf("Pages.Render() ETag for other request = %v, want %v", got, etag)
+	}
+	if got := etagOf(t, "/foo", http.StatusNotFound, "Other"); got == etag {
+		t.Errorf("Pages.Render() ETag for other message = %v, want different", got)
+	}
+	if got := etagOf(t, "/foo", http.StatusGone, "Foo bar"); got == etag {
+		t.Errorf("Pages.Render() ETag for other status = %v, want different", got)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		wantCode    int
+		wantBody    bool
+	}{
+		{
+			"Unconditional",
+			http.MethodGet,
+			"",
+			http.StatusNotFound,
+			true,
+		},
+		{
+			"Not modified",
+			http.MethodGet,
+			etag,
+			http.StatusNotModified,
+			false,
+		},
+		{
+			"Modified",
+			http.MethodGet,
+			`W/"foo"`,
+			http.StatusNotFound,
+			true,
+		},
+		{
+			"Post",
+			http.MethodPost,
+			etag,
+			http.StatusNotFound,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com/foo", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Foo bar"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("Pages.Render() ETag = %v, want %v", got, etag)
+			}
+			if hasBody := w.Body.Len() > 0; hasBody != tt.wantBody {
+				t.Errorf("Pages.Render() body = %q, wantBody %v", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPages_Render_StableETag_TemplateNamer(t *testing.T) {
+	p := &Pages{
+		Tmpl:       template.Must(template.New("error").Parse(`{{ define "503" }}503{{ end }}{{ define "maintenance" }}Back soon{{ end }}`)),
+		StableETag: true,
+		RedactFrom: -1,
+	}
+
+	etag := func(dp Provider) string {
+		w := httptest.NewRecorder()
+		if err := p.Render(w, dp); err != nil {
+			t.Fatal(err)
+		}
+		return w.Header().Get("ETag")
+	}
+
+	d := Data{Code: http.StatusServiceUnavailable, Msg: "Foo bar"}
+	if page, maintenance := etag(&d), etag(&namedData{d, "maintenance"}); page == maintenance {
+		t.Errorf("Pages.Render() ETag of named template = %v, want different from %v", maintenance, page)
+	}
+}
+
+func TestPages_Render_StableETag_options(t *testing.T) {
+	const text = `{{ define "layout" }}<main>{{ .Content }}</main>{{ end }}` +
+		`{{ define "error" }}<html lang="{{ .Lang }}">{{ .StatusText }}: {{ .Message }}</html>{{ end }}`
+
+	etag := func(opt func(*Pages)) string {
+		p := &Pages{Tmpl: template.Must(template.New("").Parse(text)), StableETag: true}
+		if opt != nil {
+			opt(p)
+		}
+
+		w := httptest.NewRecorder()
+		if err := p.Render(w, &Data{Code: http.StatusNotFound, Msg: "Foo bar"}); err != nil {
+			t.Fatal(err)
+		}
+		return w.Header().Get("ETag")
+	}
+	base := etag(nil)
+
+	tests := []struct {
+		name string
+		opt  func(*Pages)
+	}{
+		{"Lang", func(p *Pages) { p.Lang = "nl" }},
+		{"Layout", func(p *Pages) { p.Layout = "layout" }},
+		{"StatusText", func(p *Pages) { p.StatusText = map[Status]string{http.StatusNotFound: "Gone fishing"} }},
+		{"LocalizeStatus", func(p *Pages) { p.LocalizeStatus = func(string, Status) string { return "Niet gevonden" } }},
+		{"Charset", func(p *Pages) { p.Charset = "iso-8859-1" }},
+		{"Sanitizer", func(p *Pages) { p.Sanitizer = bytes.ToUpper }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etag(tt.opt); got == base {
+				t.Errorf("Pages.Render() ETag = %v, want different from %v", got, base)
+			}
+		})
+	}
+
+	if got := etag(func(p *Pages) { p.Lang = DefaultLang }); got != base {
+		t.Errorf("Pages.Render() ETag with DefaultLang = %v, want %v", got, base)
+	}
+}
+
+func TestPages_Render_EnableETag(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(etagTemplate))
+
+	render := func(p *Pages, method, path, ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		r := httptest.NewRequest(method, "http://example.com"+path, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		w := httptest.NewRecorder()
+		if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "Foo bar"}); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	p := &Pages{Tmpl: tmpl, EnableETag: true}
+
+	first := render(p, http.MethodGet, "/foo", "")
+	etag := first.Header().Get("ETag")
+	if want := bodyETag(first.Body.Bytes()); etag != want {
+		t.Fatalf("Pages.Render() ETag = %v, want %v", etag, want)
+	}
+	if got := render(p, http.MethodGet, "/bar", "").Header().Get("ETag"); got == etag {
+		t.Errorf("Pages.Render() ETag for other body = %v, want different", got)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		wantCode    int
+		wantBody    bool
+	}{
+		{"Unconditional", http.MethodGet, "", http.StatusNotFound, true},
+		{"Not modified", http.MethodGet, etag, http.StatusNotModified, false},
+		{"Not modified HEAD", http.MethodHead, etag, http.StatusNotModified, false},
+		{"Modified", http.MethodGet, `W/"foo"`, http.StatusNotFound, true},
+		{"Post", http.MethodPost, etag, http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := render(p, tt.method, "/foo", tt.ifNoneMatch)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("Pages.Render() ETag = %v, want %v", got, etag)
+			}
+			if hasBody := w.Body.Len() > 0; hasBody != tt.wantBody {
+				t.Errorf("Pages.Render() body = %q, wantBody %v", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("StableETag", func(t *testing.T) {
+		p := &Pages{Tmpl: template.Must(template.New("error").Parse(etagTemplate)), EnableETag: true, StableETag: true}
+
+		if got, other := render(p, http.MethodGet, "/foo", "").Header().Get("ETag"), etag; got == other {
+			t.Errorf("Pages.Render() ETag = %v, want the StableETag", got)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		if got := render(&Pages{Tmpl: tmpl}, http.MethodGet, "/foo", etag); got.Code != http.StatusNotFound || got.Header().Get("ETag") != "" {
+			t.Errorf("Pages.Render() status = %v, ETag = %q, want %v without ETag", got.Code, got.Header().Get("ETag"), http.StatusNotFound)
+		}
+	})
+}
+
+func TestPages_Render_renderErrorCaching(t *testing.T) {
+	p := &Pages{
+		Tmpl:         template.Must(template.New("error").Parse(`{{ define "404" }}{{ .Foo }}{{ end }}`)),
+		StableETag:   true,
+		CacheControl: map[Status]string{http.StatusNotFound: "max-age=3600"},
+	}
+	w := httptest.NewRecorder()
+	w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+
+	d := &Data{Req: httptest.NewRequest(http.MethodGet, "/", nil), Code: http.StatusNotFound}
+	if err := p.Render(w, d); !errors.Is(err, ErrTemplateExec) {
+		t.Fatalf("Pages.Render() error = %v, want %v", err, ErrTemplateExec)
+	}
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Pages.Render() status = %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+	for _, k := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+		if got := w.Header().Get(k); got != "" {
+			t.Errorf("Pages.Render() %s = %q, want none", k, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+// FieldProvider can optionally be implemented by a Provider,
+// to expose arbitrary values to the templates by key, as `{{ .Field "SupportEmail" }}`.
+// Data implements it for its Fields.
+//
+// Fields avoid defining a Provider type per page for a few extra values.
+// Embedding Data in a custom type remains the way for typed values and methods:
+// both approaches can be combined, as the embedding type gets Field from Data.
+type FieldProvider interface {
+	Field(key string) interface{}
+}
+
+// Field returns the value for key from Fields, or nil if not set.
+func (d *Data) Field(key string) interface{} { return d.Fields[key] }
+
+// Field returns the value for key from the Provider, if it implements FieldProvider.
+// It allows for `{{ .Field "key" }}` when Render wraps the Provider,
+// such as for redaction, which hides the `.Fields` of Data.
+func (pg *page) Field(key string) interface{} {
+	if fp, ok := optional[FieldProvider](pg.Provider); ok {
+		return fp.Field(key)
+	}
+	return nil
+}
+
+// fieldMap returns Fields, for `.Fields` on a page.
+func (d *Data) fieldMap() map[string]interface{} { return d.Fields }
+
+// Fields returns the Fields of the Data of the Provider, if any,
+// so `{{ .Fields.SupportEmail }}` keeps working when Render wraps the Provider.
+func (pg *page) Fields() map[string]interface{} {
+	if fm, ok := optional[interface{ fieldMap() map[string]interface{} }](pg.Provider); ok {
+		return fm.fieldMap()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestData_Field(t *testing.T) {
+	d := &Data{Fields: map[string]interface{}{"SupportEmail": "help@example.com"}}
+
+	if got := d.Field("SupportEmail"); got != "help@example.com" {
+		t.Errorf("Data.Field() = %v, want %v", got, "help@example.com")
+	}
+	if got := d.Field("Missing"); got != nil {
+		t.Errorf("Data.Field() = %v, want nil", got)
+	}
+	if got := new(Data).Field("SupportEmail"); got != nil {
+		t.Errorf("Data.Field() = %v, want nil", got)
+	}
+}
+
+func TestPages_Render_Fields(t *testing.T) {
+	fields := map[string]interface{}{"SupportEmail": "help@example.com"}
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		tmpl  string
+		dp    Provider
+	}{
+		{
+			"Fields",
+			&Pages{},
+			`{{ .Fields.SupportEmail }}`,
+			&Data{Code: http.StatusNotFound, Fields: fields},
+		},
+		{
+			"Field",
+			&Pages{},
+			`{{ .Field "SupportEmail" }}`,
+			&Data{Code: http.StatusNotFound, Fields: fields},
+		},
+		{
+			"Embedded",
+			&Pages{},
+			`{{ .Field "SupportEmail" }}`,
+			&logData{Data{Code: http.StatusNotFound, Fields: fields}},
+		},
+		{
+			"Page",
+			&Pages{Lang: "en"},
+			`{{ .Field "SupportEmail" }}`,
+			&Data{Code: http.StatusNotFound, Fields: fields},
+		},
+		{
+			"Redacted",
+			&Pages{},
+			`{{ .Field "SupportEmail" }}`,
+			&Data{Code: http.StatusInternalServerError, Msg: "secret", Fields: fields},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pages.Tmpl = template.Must(template.New("error").Parse(tt.tmpl))
+			w := httptest.NewRecorder()
+
+			if err := tt.pages.Render(w, tt.dp); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != "help@example.com" {
+				t.Errorf("Pages.Render() = %q, want %q", got, "help@example.com")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// PartialPrefix is stripped from template file names,
+// so partials can be told apart from pages in a directory listing.
+// Eg: "_footer.html" defines the partial "footer".
+const PartialPrefix = "_"
+
+// ParseFS creates Pages from the template files in fsys matching the patterns,
+// as accepted by fs.Glob.
+// Template names are derived from the file names, without extension:
+//
+//   - A status code, like "404.html", defines a status page.
+//   - "timeout.html" defines the page for 408 and 504.
+//   - A status class, like "4xx.html", defines the page for all statuses in the class.
+//   - "error.html" defines the generic error page.
+//   - Any other file, like "head.html", defines a partial,
+//     which status pages include with `{{ template "head" . }}`.
+//     The PartialPrefix is stripped, so "_footer.html" defines "footer".
+//
+// `{{ define }}` blocks in any of the parsed files are available to all pages.
+// The templates can use DefaultFuncs and the "partial" func, see AddPartialFunc.
+func ParseFS(fsys fs.FS, patterns ...string) (*Pages, error) {
+	return ParseFSFuncs(fsys, nil, patterns...)
+}
+
+// ParseFSFuncs is like ParseFS, with funcs added to the set before parsing.
+func ParseFSFuncs(fsys fs.FS, funcs template.FuncMap, patterns ...string) (*Pages, error) {
+	tmpl, err := parseFS(fsys, patterns, funcs)
+	if err != nil {
+		return nil, fmt.Errorf("ehtml ParseFS: %w", err)
+	}
+	return &Pages{Tmpl: tmpl, funcs: funcs}, nil
+}
+
+// parseFS parses the files matching patterns into a new set,
+// with DefaultFuncs, the partial func and funcs added.
+func parseFS(fsys fs.FS, patterns []string, funcs template.FuncMap) (*template.Template, error) {
+	var tmpl *template.Template
+
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("pattern %q matches no files", pattern)
+		}
+
+		for _, file := range files {
+			name, ok := templateName(file)
+			if !ok {
+				continue
+			}
+
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+
+			var t *template.Template
+			if tmpl == nil {
+				tmpl = AddPartialFunc(template.New(name).Funcs(DefaultFuncs())).Funcs(funcs)
+				t = tmpl
+			} else {
+				t = tmpl.New(name)
+			}
+			if _, err = t.Parse(string(b)); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+
+	return tmpl, nil
+}
+
+// ParseDir is like ParseFS, for the template files in the directory dir.
+func ParseDir(dir string, patterns ...string) (*Pages, error) {
+	return ParseFS(os.DirFS(dir), patterns...)
+}
+
+// templateName derives the template name from a file name.
+// It returns false if the file has no usable name, like "_.html".
+func templateName(file string) (string, bool) {
+	name := path.Base(file)
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.TrimPrefix(name, PartialPrefix)
+
+	return name, name != ""
+}
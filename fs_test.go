@@ -0,0 +1,160 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseDir(t *testing.T) {
+	p, err := ParseDir("testdata/templates", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		code Status
+		want string
+	}{
+		{
+			"Status page",
+			http.StatusNotFound,
+			"<h1>404 Not Found</h1><p>/foo not found</p><footer>eHTML</footer>",
+		},
+		{
+			"Generic page",
+			http.StatusBadRequest,
+			"<h1>400 Bad Request</h1><p>Foo bar</p><footer>eHTML</footer>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+				Msg:  "Foo bar",
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := ioutil.ReadAll(w.Result().Body)
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = \n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+
+	if p.Tmpl.Lookup("notes") == nil {
+		t.Error("ParseDir() did not parse notes.txt as partial")
+	}
+}
+
+func TestParseFS_error(t *testing.T) {
+	fsys := fstest.MapFS{
+		"404.html": &fstest.MapFile{Data: []byte("{{ .Foo")},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+	}{
+		{
+			"No match",
+			[]string{"*.tmpl"},
+		},
+		{
+			"Bad pattern",
+			[]string{"["},
+		},
+		{
+			"Parse error",
+			[]string{"*.html"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFS(fsys, tt.patterns...); err == nil {
+				t.Errorf("ParseFS() error = %v, wantErr %v", err, true)
+			}
+		})
+	}
+}
+
+func TestParseFSFuncs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"error.html": {Data: []byte(`{{ partial "head" . }}{{ statusText 404 }} {{ shout .Message }}`)},
+		"head.html":  {Data: []byte(`<h1>{{ .Status.Int }}</h1>`)},
+	}
+	funcs := template.FuncMap{"shout": strings.ToUpper}
+
+	tests := []struct {
+		name string
+		p    func() (*Pages, error)
+	}{
+		{
+			"ParseFSFuncs",
+			func() (*Pages, error) { return ParseFSFuncs(fsys, funcs, "*.html") },
+		},
+		{
+			"WatchFS",
+			func() (*Pages, error) {
+				p := new(Pages).Funcs(funcs)
+				return p, p.WatchFS(fsys, "*.html")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.p()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, &Data{Code: http.StatusNotFound, Msg: "foo"}); err != nil {
+				t.Fatal(err)
+			}
+			const want = "<h1>404</h1>Not Found FOO"
+			if got := w.Body.String(); got != want {
+				t.Errorf("Pages.Render() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func Test_templateName(t *testing.T) {
+	tests := []struct {
+		file   string
+		want   string
+		wantOK bool
+	}{
+		{"templates/404.html", "404", true},
+		{"error.html", "error", true},
+		{"_footer.html", "footer", true},
+		{"_.html", "", false},
+		{"timeout.html", "timeout", true},
+		{"notes.txt", "notes", true},
+		{"head.html", "head", true},
+		{".html", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, ok := templateName(tt.file)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("templateName() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
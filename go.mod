@@ -1,5 +1,8 @@
 module github.com/moapis/ehtml
 
-go 1.13
+go 1.20
 
-require github.com/gorilla/mux v1.7.4
+require (
+	github.com/gorilla/mux v1.7.4
+	golang.org/x/text v0.14.0
+)
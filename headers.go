@@ -0,0 +1,194 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultMaxHeaderBytes is used when Pages.MaxHeaderBytes is 0.
+const DefaultMaxHeaderBytes = 8 << 10
+
+// headerSetter sets optional headers, up to a total of max bytes.
+type headerSetter struct {
+	h   http.Header
+	max int
+	n   int
+	log *log.Logger
+}
+
+func (p *Pages) headerSetter(h http.Header) *headerSetter {
+	max := p.MaxHeaderBytes
+	if max == 0 {
+		max = DefaultMaxHeaderBytes
+	}
+
+	return &headerSetter{
+		h:   h,
+		max: max,
+		log: p.ErrorLog,
+	}
+}
+
+// Set the header, unless that would exceed the limit.
+// Dropped headers are logged.
+func (hs *headerSetter) Set(key, value string) {
+	// As written on the wire: "Key: value\r\n"
+	size := len(key) + len(value) + 4
+
+	if hs.max > 0 && hs.n+size > hs.max {
+		if hs.log != nil {
+			hs.log.Printf("ehtml: dropped header %s of %d bytes, exceeding MaxHeaderBytes", key, size)
+		}
+		return
+	}
+
+	hs.n += size
+	hs.h.Set(key, value)
+}
+
+// varies reports whether the Vary header of h lists key.
+func varies(h http.Header, key string) bool {
+	for _, v := range h.Values("Vary") {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k == "*" || strings.EqualFold(k, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addVary adds key to the Vary header of h, unless listed already.
+func addVary(h http.Header, key string) {
+	if !varies(h, key) {
+		h.Add("Vary", key)
+	}
+}
+
+// isToken reports whether s is a valid token, as used for header and cookie names.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > '~' || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHeaderValue reports whether s can be sent as header value,
+// which must not contain control characters other than tab.
+func isHeaderValue(s string) bool {
+	for _, c := range s {
+		if (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateHeaders checks the options which produce headers,
+// and returns an error for each invalid value.
+// It is meant to be called at startup, to catch configuration mistakes
+// before they hit clients, as Render can't report them.
+func (p *Pages) ValidateHeaders() []error {
+	var errs []error
+	invalid := func(option, value, reason string) {
+		errs = append(errs, fmt.Errorf("ehtml ValidateHeaders: %s %q: %s", option, value, reason))
+	}
+
+	for _, k := range p.StripHeaders {
+		if !isToken(k) {
+			invalid("StripHeaders", k, "invalid header name")
+		}
+	}
+
+	if p.Lang != "" {
+		if _, err := language.Parse(p.Lang); err != nil {
+			invalid("Lang", p.Lang, err.Error())
+		}
+	}
+
+	names := make([]string, 0, len(p.Locales))
+	for name := range p.Locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := language.Parse(name); err != nil {
+			invalid("Locales", name, err.Error())
+		}
+	}
+
+	// A nonce of the size Render generates, for the header size.
+	if key, value := p.cspHeader(strings.Repeat("x", base64.RawURLEncoding.EncodedLen(nonceBytes))); key != "" {
+		if !isHeaderValue(value) {
+			invalid("CSP", value, "invalid header value")
+		}
+
+		max := p.MaxHeaderBytes
+		if max == 0 {
+			max = DefaultMaxHeaderBytes
+		}
+		if size := len(key) + len(value) + 4; max > 0 && size > max {
+			invalid("CSP", value, fmt.Sprintf("%d bytes exceeds MaxHeaderBytes", size))
+		}
+	}
+	if p.CSPReportURI != "" {
+		if _, err := url.Parse(p.CSPReportURI); err != nil || strings.ContainsAny(p.CSPReportURI, " ;,") {
+			invalid("CSPReportURI", p.CSPReportURI, "invalid URI")
+		}
+	}
+	if p.CSPReportTo != "" && !isToken(p.CSPReportTo) {
+		invalid("CSPReportTo", p.CSPReportTo, "invalid group name")
+	}
+
+	statuses := make([]Status, 0, len(p.Redirects))
+	for s := range p.Redirects {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+
+	for _, s := range statuses {
+		target := p.Redirects[s]
+		if _, err := url.Parse(target); err != nil || target == "" || !isHeaderValue(target) {
+			invalid(fmt.Sprintf("Redirects[%d]", s), target, "invalid URL")
+		}
+	}
+
+	statuses = statuses[:0]
+	for s := range p.CacheControl {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+
+	for _, s := range statuses {
+		if cc := p.CacheControl[s]; cc == "" || !isHeaderValue(cc) {
+			invalid(fmt.Sprintf("CacheControl[%d]", s), cc, "invalid header value")
+		}
+	}
+
+	if p.WWWAuthenticate != "" && !isHeaderValue(p.WWWAuthenticate) {
+		invalid("WWWAuthenticate", p.WWWAuthenticate, "invalid header value")
+	}
+
+	if p.FlashCookie != "" && !isToken(p.FlashCookie) {
+		invalid("FlashCookie", p.FlashCookie, "invalid cookie name")
+	}
+
+	return errs
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPages_headerSetter(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		want    http.Header
+		wantLog bool
+	}{
+		{
+			"Default",
+			0,
+			http.Header{
+				"X-Foo": []string{"Bar"},
+				"X-Big": []string{strings.Repeat("x", 1000)},
+			},
+			false,
+		},
+		{
+			"Exceeded",
+			100,
+			http.Header{
+				"X-Foo": []string{"Bar"},
+			},
+			true,
+		},
+		{
+			"Unlimited",
+			-1,
+			http.Header{
+				"X-Foo": []string{"Bar"},
+				"X-Big": []string{strings.Repeat("x", 1000)},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &Pages{
+				MaxHeaderBytes: tt.max,
+				ErrorLog:       log.New(&buf, "", 0),
+			}
+
+			h := make(http.Header)
+			hs := p.headerSetter(h)
+			hs.Set("X-Foo", "Bar")
+			hs.Set("X-Big", strings.Repeat("x", 1000))
+
+			if !reflect.DeepEqual(h, tt.want) {
+				t.Errorf("headerSetter.Set() = %v, want %v", h, tt.want)
+			}
+			if got := buf.Len() > 0; got != tt.wantLog {
+				t.Errorf("headerSetter.Set() logged = %v, want %v", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestPages_ValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Pages
+		want []string
+	}{
+		{
+			"Zero",
+			&Pages{},
+			nil,
+		},
+		{
+			"Valid",
+			&Pages{
+				StripHeaders:  []string{"Cache-Control", "X-Foo"},
+				Lang:          "en-US",
+				Locales:       map[string]*template.Template{"nl": nil, "de-CH": nil},
+				CSP:           "default-src 'none'",
+				CSPReportOnly: true,
+				CSPReportURI:  "https://example.com/csp",
+				CSPReportTo:   "csp-endpoint",
+				Redirects:     map[Status]string{http.StatusUnauthorized: "/login?next=%2F"},
+				CacheControl:  map[Status]string{http.StatusNotFound: "no-store"},
+				FlashCookie:   "flash",
+			},
+			nil,
+		},
+		{
+			"Invalid",
+			&Pages{
+				StripHeaders: []string{"X Foo", ""},
+				Lang:         "english!",
+				Locales:      map[string]*template.Template{"nl": nil, "x": nil},
+				CSP:          "default-src 'none'\r\nX-Injected: 1",
+				CSPReportURI: "/csp reports",
+				CSPReportTo:  "csp endpoint",
+				Redirects: map[Status]string{
+					http.StatusForbidden:    "",
+					http.StatusUnauthorized: "/login\n",
+				},
+				CacheControl: map[Status]string{
+					http.StatusServiceUnavailable: "max-age=30\r\nX-Injected: 1",
+					http.StatusNotFound:           "",
+				},
+				WWWAuthenticate: "Basic\nrealm",
+				FlashCookie:     "flash;",
+			},
+			[]string{
+				`ehtml ValidateHeaders: StripHeaders "X Foo": invalid header name`,
+				`ehtml ValidateHeaders: StripHeaders "": invalid header name`,
+				`ehtml ValidateHeaders: Lang "english!": language: tag is not well-formed`,
+				`ehtml ValidateHeaders: Locales "x": language: tag is not well-formed`,
+				`ehtml ValidateHeaders: CSP "default-src 'none'\r\nX-Injected: 1; report-uri /csp reports; report-to csp endpoint": invalid header value`,
+				`ehtml ValidateHeaders: CSPReportURI "/csp reports": invalid URI`,
+				`ehtml ValidateHeaders: CSPReportTo "csp endpoint": invalid group name`,
+				`ehtml ValidateHeaders: Redirects[401] "/login\n": invalid URL`,
+				`ehtml ValidateHeaders: Redirects[403] "": invalid URL`,
+				`ehtml ValidateHeaders: CacheControl[404] "": invalid header value`,
+				`ehtml ValidateHeaders: CacheControl[503] "max-age=30\r\nX-Injected: 1": invalid header value`,
+				`ehtml ValidateHeaders: WWWAuthenticate "Basic\nrealm": invalid header value`,
+				`ehtml ValidateHeaders: FlashCookie "flash;": invalid cookie name`,
+			},
+		},
+		{
+			"CSP too big",
+			&Pages{
+				CSP:            "default-src " + strings.Repeat("x", 100),
+				MaxHeaderBytes: 100,
+			},
+			[]string{
+				`ehtml ValidateHeaders: CSP "default-src ` + strings.Repeat("x", 100) + `": 139 bytes exceeds MaxHeaderBytes`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.p.ValidateHeaders()
+
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pages.ValidateHeaders() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// statusJSON is the JSON representation of Status.
+type statusJSON struct {
+	Code int    `json:"code"`
+	Text string `json:"text"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// The status is encoded as an object with its code and text, as returned by String():
+//
+//	{"code":404,"text":"Not Found"}
+//
+// The text is empty for unknown codes.
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(statusJSON{Code: s.Int(), Text: s.String()})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It accepts the object produced by MarshalJSON, of which only the code is used,
+// or a plain number. JSON null leaves s unchanged.
+func (s *Status) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	if len(b) > 0 && b[0] != '{' {
+		var code int
+		if err := json.Unmarshal(b, &code); err != nil {
+			return fmt.Errorf("ehtml Status: %w", err)
+		}
+		*s = Status(code)
+		return nil
+	}
+
+	var sj statusJSON
+	if err := json.Unmarshal(b, &sj); err != nil {
+		return fmt.Errorf("ehtml Status: %w", err)
+	}
+	*s = Status(sj.Code)
+	return nil
+}
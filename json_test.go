@@ -0,0 +1,92 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStatus_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		s    Status
+		want string
+	}{
+		{404, `{"code":404,"text":"Not Found"}`},
+		{500, `{"code":500,"text":"Internal Server Error"}`},
+		{999, `{"code":999,"text":""}`},
+		{0, `{"code":0,"text":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s.toA(), func(t *testing.T) {
+			b, err := json.Marshal(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tt.want {
+				t.Errorf("Status.MarshalJSON() = %s, want %s", got, tt.want)
+			}
+
+			var got Status
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.s {
+				t.Errorf("Status.UnmarshalJSON() = %d, want %d", got, tt.s)
+			}
+		})
+	}
+}
+
+func TestStatus_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Status
+		wantErr bool
+	}{
+		{"Object", `{"code":404,"text":"Not Found"}`, 404, false},
+		{"Other text", `{"code":404,"text":"Nope"}`, 404, false},
+		{"Code only", `{"code":503}`, 503, false},
+		{"Number", `418`, 418, false},
+		{"Null", `null`, 500, false},
+		{"String", `"404"`, 500, true},
+		{"Bad code", `{"code":"404"}`, 500, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Status(500)
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Status.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Status.UnmarshalJSON() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus_JSON_field(t *testing.T) {
+	type resp struct {
+		Status Status `json:"status"`
+	}
+
+	b, err := json.Marshal(resp{Status: 410})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"status":{"code":410,"text":"Gone"}}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	var got resp
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != 410 {
+		t.Errorf("json.Unmarshal() = %d, want %d", got.Status, 410)
+	}
+}
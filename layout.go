@@ -0,0 +1,42 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io"
+)
+
+// layout returns the Layout template of set, if tmpl is a page of set which it wraps.
+func (p *Pages) layout(set, tmpl *template.Template) *template.Template {
+	if p.Layout == "" || set == nil || tmpl.Name() == p.Layout || set.Lookup(tmpl.Name()) != tmpl {
+		return nil
+	}
+	return set.Lookup(p.Layout)
+}
+
+// executeLayout executes the page tmpl of set with data to w.
+// When set has the Layout, the page is executed into its Content first
+// and the layout is executed to w instead.
+func (p *Pages) executeLayout(w io.Writer, set, tmpl *template.Template, data interface{}) error {
+	defer p.executed(set, tmpl)
+
+	layout := p.layout(set, tmpl)
+	pg, ok := data.(*page)
+	if layout == nil || !ok {
+		return tmpl.Execute(w, data)
+	}
+
+	buf := buffers.Get()
+	defer buffers.Put(buf)
+
+	if err := tmpl.Execute(buf, data); err != nil {
+		return err
+	}
+	// The page is escaped by html/template already.
+	pg.Content = template.HTML(buf.String())
+
+	return layout.Execute(w, pg)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_Render_Layout(t *testing.T) {
+	const layout = `
+		{{ define "layout" }}<main class="c{{ .Status.Class }}">{{ .Content }}</main>{{ end }}
+		{{ define "404" }}<p>{{ .Message }}</p>{{ end }}
+		{{ define "error" }}<p>{{ .Status.Int }}</p>{{ end }}
+	`
+
+	tests := []struct {
+		name  string
+		pages *Pages
+		code  Status
+		want  string
+	}{
+		{
+			"Status page",
+			&Pages{Tmpl: template.Must(template.New("").Parse(layout)), Layout: "layout"},
+			http.StatusNotFound,
+			`<main class="c4"><p>&lt;b&gt;</p></main>`,
+		},
+		{
+			"Error page",
+			&Pages{Tmpl: template.Must(template.New("").Parse(layout)), Layout: "layout"},
+			http.StatusGone,
+			`<main class="c4"><p>410</p></main>`,
+		},
+		{
+			"No Layout",
+			&Pages{Tmpl: template.Must(template.New("").Parse(layout))},
+			http.StatusNotFound,
+			`<p>&lt;b&gt;</p>`,
+		},
+		{
+			"No layout in set",
+			&Pages{Tmpl: template.Must(template.New("").Parse(`{{ define "404" }}<p>{{ .Message }}</p>{{ end }}`)), Layout: "layout"},
+			http.StatusNotFound,
+			`<p>&lt;b&gt;</p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, &Data{Code: tt.code, Msg: "<b>"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_LayoutDefault(t *testing.T) {
+	p := &Pages{
+		Tmpl:   template.Must(template.New("layout").Parse(`<main>{{ .Content }}</main>`)),
+		Layout: "layout",
+	}
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Body.String(); strings.Contains(got, "<main>") || !strings.Contains(got, "<!DOCTYPE html>") {
+		t.Errorf("Pages.Render() = %q, want DefaultTmpl without layout", got)
+	}
+}
+
+func TestPages_RenderStream_Layout(t *testing.T) {
+	p := &Pages{
+		Tmpl:   template.Must(template.New("").Parse(`{{ define "layout" }}<main>{{ .Content }}</main>{{ end }}{{ define "error" }}{{ .Message }}{{ end }}`)),
+		Layout: "layout",
+	}
+	w := httptest.NewRecorder()
+	if err := p.RenderStream(w, &Data{Code: http.StatusNotFound, Msg: "Not here"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), "<main>Not here</main>"; got != want {
+		t.Errorf("Pages.RenderStream() = %q, want %q", got, want)
+	}
+}
+
+func TestPages_Validate_Layout(t *testing.T) {
+	p := &Pages{
+		Tmpl:   template.Must(template.New("").Parse(`{{ define "layout" }}{{ .Content }}{{ end }}{{ define "error" }}{{ end }}`)),
+		Layout: "layout",
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Pages.Validate() = %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "net/http"
+
+// LegalBlocker can optionally be implemented by a Provider,
+// to identify who blocked the resource on a "451 Unavailable For Legal Reasons" page,
+// as described in RFC 7725.
+// Render sets the `Link: <authority>; rel="blocked-by"` header,
+// and exposes the values to the template as `.BlockedBy.Authority` and `.BlockedBy.Ref`.
+// It is ignored for all other statuses.
+type LegalBlocker interface {
+	// BlockedBy returns the URI of the entity implementing the block
+	// and a reference to the legal demand, such as a case number.
+	// The Link header is omitted if authority is empty.
+	BlockedBy() (authority string, ref string)
+}
+
+// blockedBy is exposed to the templates as `.BlockedBy`.
+type blockedBy struct {
+	Authority string
+	Ref       string
+}
+
+func blockedByOf(dp Provider) *blockedBy {
+	if dp.Status() != http.StatusUnavailableForLegalReasons {
+		return nil
+	}
+
+	lb, ok := optional[LegalBlocker](dp)
+	if !ok {
+		return nil
+	}
+
+	authority, ref := lb.BlockedBy()
+	return &blockedBy{
+		Authority: authority,
+		Ref:       ref,
+	}
+}
+
+func (bb *blockedBy) setHeaders(h *headerSetter) {
+	if bb.Authority != "" {
+		h.Set("Link", "<"+bb.Authority+`>; rel="blocked-by"`)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type legalData struct {
+	Data
+	authority string
+}
+
+func (d *legalData) BlockedBy() (string, string) { return d.authority, "Case 42" }
+
+func TestPages_Render_BlockedBy(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(
+		`{{ define "451" }}{{ .BlockedBy.Authority }} {{ .BlockedBy.Ref }}{{ end }}{{ define "error" }}{{ .Message }}{{ end }}`,
+	))
+
+	tests := []struct {
+		name      string
+		code      Status
+		authority string
+		want      string
+		wantLink  string
+	}{
+		{
+			"Unavailable for legal reasons",
+			http.StatusUnavailableForLegalReasons,
+			"https://authority.example.org/",
+			"https://authority.example.org/ Case 42",
+			`<https://authority.example.org/>; rel="blocked-by"`,
+		},
+		{
+			"No authority",
+			http.StatusUnavailableForLegalReasons,
+			"",
+			" Case 42",
+			"",
+		},
+		{
+			"Other status",
+			http.StatusForbidden,
+			"https://authority.example.org/",
+			"Foo bar",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: tmpl}
+			d := &legalData{
+				Data{
+					Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+					Code: tt.code,
+					Msg:  "Foo bar",
+				},
+				tt.authority,
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+			if got := resp.Header.Get("Link"); got != tt.wantLink {
+				t.Errorf("Pages.Render() header Link = %v, want %v", got, tt.wantLink)
+			}
+		})
+	}
+}
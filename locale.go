@@ -0,0 +1,87 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// NewMultilingual returns Pages serving a template set per language.
+// The set matching the Accept-Language header of the request is used,
+// as with Locales, and the set for defaultLang when nothing matches.
+// DefaultTmpl is used if sets has no entry for defaultLang.
+// Use LocalizeStatus on the returned Pages to translate the status texts.
+func NewMultilingual(defaultLang string, sets map[string]*template.Template) *Pages {
+	return &Pages{
+		Tmpl:    sets[defaultLang],
+		Locales: sets,
+		Lang:    defaultLang,
+	}
+}
+
+// localeMatcher matches Accept-Language headers to the language tags of Locales.
+// It is built once, on first use.
+type localeMatcher struct {
+	once    sync.Once
+	matcher language.Matcher
+	// names of Locales, by index of the supported tags of matcher.
+	names []string
+}
+
+// matcher returns the localeMatcher for Locales.
+func (p *Pages) matcher() *localeMatcher {
+	lm := &p.localeMatcher
+	lm.once.Do(func() {
+		names := make([]string, 0, len(p.Locales))
+		for name := range p.Locales {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		// The first supported tag is returned when nothing matches,
+		// und stands for Tmpl.
+		supported := []language.Tag{language.Und}
+		lm.names = []string{""}
+
+		for _, name := range names {
+			tag, err := language.Parse(name)
+			if err != nil {
+				continue
+			}
+			supported = append(supported, tag)
+			lm.names = append(lm.names, name)
+		}
+
+		lm.matcher = language.NewMatcher(supported)
+	})
+	return lm
+}
+
+// locale returns the template set from Locales which best matches
+// the Accept-Language header of r, and its language tag.
+// It returns a nil template if there is no match.
+func (p *Pages) locale(r *http.Request) (*template.Template, string) {
+	if len(p.Locales) == 0 || r == nil {
+		return nil, ""
+	}
+
+	accept, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(accept) == 0 {
+		return nil, ""
+	}
+
+	lm := p.matcher()
+	_, i, conf := lm.matcher.Match(accept...)
+	if i == 0 || conf == language.No {
+		return nil, ""
+	}
+
+	return p.Locales[lm.names[i]], lm.names[i]
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_Render_Locales(t *testing.T) {
+	p := &Pages{
+		Tmpl: template.Must(template.New("error").Parse("Not found")),
+		Locales: map[string]*template.Template{
+			"fr":    template.Must(template.New("error").Parse("Introuvable")),
+			"nl":    template.Must(template.New("error").Parse("Niet gevonden")),
+			"nl-BE": template.Must(template.New("error").Parse("Niet teruggevonden")),
+			"@@":    template.Must(template.New("error").Parse("Invalid")),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		accept   string
+		want     string
+		wantLang string
+	}{
+		{
+			"No header",
+			"",
+			"Not found",
+			"",
+		},
+		{
+			"Exact",
+			"fr",
+			"Introuvable",
+			"fr",
+		},
+		{
+			"Regional fallback",
+			"fr-CA",
+			"Introuvable",
+			"fr",
+		},
+		{
+			"Regional variant",
+			"nl-BE, en;q=0.5",
+			"Niet teruggevonden",
+			"nl-BE",
+		},
+		{
+			"Quality",
+			"de, en;q=0.8, nl;q=0.5",
+			"Niet gevonden",
+			"nl",
+		},
+		{
+			"Unsupported",
+			"ja",
+			"Not found",
+			"",
+		},
+		{
+			"Malformed",
+			"!!;q=x",
+			"Not found",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, &Data{Req: r, Code: http.StatusNotFound}); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+			if got := resp.Header.Get("Content-Language"); got != tt.wantLang {
+				t.Errorf("Pages.Render() Content-Language = %v, want %v", got, tt.wantLang)
+			}
+			if !varies(resp.Header, "Accept-Language") {
+				t.Errorf("Pages.Render() Vary = %v, want Accept-Language", resp.Header.Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestPages_Render_noLocales(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	r.Header.Set("Accept-Language", "fr")
+
+	w := httptest.NewRecorder()
+	if err := new(Pages).Render(w, &Data{Req: r, Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if varies(w.Header(), "Accept-Language") {
+		t.Errorf("Pages.Render() Vary = %v, want no Accept-Language", w.Header().Values("Vary"))
+	}
+}
+
+func BenchmarkPages_locale(b *testing.B) {
+	p := &Pages{
+		Locales: map[string]*template.Template{
+			"fr":    template.New("error"),
+			"nl":    template.New("error"),
+			"nl-BE": template.New("error"),
+		},
+	}
+	r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	r.Header.Set("Accept-Language", "nl-BE, en;q=0.5")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.locale(r)
+	}
+}
+
+func TestNewMultilingual(t *testing.T) {
+	p := NewMultilingual("en", map[string]*template.Template{
+		"en": template.Must(template.New("error").Parse("{{ .Lang }}: {{ .StatusText }}")),
+		"nl": template.Must(template.New("error").Parse("{{ .Lang }}: {{ .StatusText }}")),
+	})
+	p.LocalizeStatus = func(lang string, s Status) string {
+		if lang == "nl" && s == http.StatusNotFound {
+			return "Niet gevonden"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name   string
+		accept string
+		code   int
+		want   string
+	}{
+		{"Default", "", http.StatusNotFound, "en: Not Found"},
+		{"No match", "fr", http.StatusNotFound, "en: Not Found"},
+		{"Localized", "nl", http.StatusNotFound, "nl: Niet gevonden"},
+		{"Not localized", "nl", http.StatusGone, "nl: Gone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Language", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, &Data{Req: r, Code: Status(tt.code)}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMultilingual_default(t *testing.T) {
+	p := NewMultilingual("de", nil)
+	p.LocalizeStatus = func(lang string, s Status) string {
+		return "Nicht gefunden"
+	}
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<h1>404 Nicht gefunden</h1>"
+	if got := w.Body.String(); !strings.Contains(got, want) {
+		t.Errorf("Pages.Render() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "time"
+
+// Observer receives metrics of rendered pages, see Pages.Observer.
+type Observer interface {
+	// Observed is called after a page with status was rendered,
+	// taking duration, including writing to the client.
+	// templateName is the name of the executed template:
+	// the status code, "timeout", the class (eg: "4xx"), "error"
+	// or DefaultTmplName when the built-in default was used,
+	// allowing to alert on pages which unexpectedly fall back.
+	// It is empty when no template was executed,
+	// as for redirects, 304 Not Modified and RenderProblem.
+	// renderErr is the error returned by the Render method.
+	Observed(status Status, templateName string, duration time.Duration, renderErr error)
+}
+
+// LogStringer can optionally be implemented by a Provider,
+// to provide a richer representation for logs than String(),
+// which is also shown to the client.
+type LogStringer interface {
+	LogString() string
+}
+
+// LogString returns the representation of dp for logging:
+// LogString() if dp implements LogStringer, String() otherwise.
+func LogString(dp Provider) string {
+	if ls, ok := optional[LogStringer](dp); ok {
+		return ls.LogString()
+	}
+	return dp.String()
+}
+
+// logError logs err to ErrorLog, if both are set, and returns err.
+func (p *Pages) logError(err error, dp Provider) error {
+	if err != nil && p.ErrorLog != nil {
+		p.ErrorLog.Printf("%v; while rendering %s", err, LogString(dp))
+	}
+	return err
+}
+
+// rendered logs err and calls Observer, OnRender and OnRenderN, once a page for dp has been sent
+// or failed to render. name is the executed template, start the time rendering began
+// and n the number of bytes written to the client.
+// It returns err.
+func (p *Pages) rendered(dp Provider, name string, start time.Time, n int64, err error) error {
+	p.logError(err, dp)
+	if p.Observer != nil {
+		p.Observer.Observed(dp.Status(), name, time.Since(start), err)
+	}
+	if p.OnRender != nil {
+		p.OnRender(dp, err)
+	}
+	if p.OnRenderN != nil {
+		p.OnRenderN(dp, n, err)
+	}
+	return err
+}
+
+// logBody logs the body of a server error page, if enabled by Log5xxBody.
+func (p *Pages) logBody(body []byte, dp Provider) {
+	if !p.Log5xxBody || p.ErrorLog == nil {
+		return
+	}
+	if !dp.Status().IsServerError() {
+		return
+	}
+	p.ErrorLog.Printf("ehtml: rendered %s:\n%s", LogString(dp), body)
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type logData struct {
+	Data
+}
+
+func (d *logData) LogString() string { return d.String() + " (user 42)" }
+
+func TestLogString(t *testing.T) {
+	d := Data{Code: http.StatusNotFound, Msg: "Foo bar"}
+
+	tests := []struct {
+		name string
+		dp   Provider
+		want string
+	}{
+		{
+			"String",
+			&d,
+			"404 Not Found: Foo bar",
+		},
+		{
+			"LogString",
+			&logData{d},
+			"404 Not Found: Foo bar (user 42)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LogString(tt.dp); got != tt.want {
+				t.Errorf("LogString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_ErrorLog(t *testing.T) {
+	errTmpl := template.Must(template.New("error").Parse("{{ .Missing }}"))
+
+	tests := []struct {
+		name    string
+		tmpl    *template.Template
+		wantLog bool
+	}{
+		{
+			"Success",
+			nil,
+			false,
+		},
+		{
+			"Failure",
+			errTmpl,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &Pages{
+				Tmpl:     tt.tmpl,
+				ErrorLog: log.New(&buf, "", 0),
+			}
+			d := &logData{Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: http.StatusNotFound,
+				Msg:  "Foo bar",
+			}}
+
+			p.Render(httptest.NewRecorder(), d)
+
+			got := buf.String()
+			if (got != "") != tt.wantLog {
+				t.Fatalf("Pages.Render() log = %q, wantLog %v", got, tt.wantLog)
+			}
+			if tt.wantLog && !bytes.HasSuffix(buf.Bytes(), []byte("while rendering 404 Not Found: Foo bar (user 42)\n")) {
+				t.Errorf("Pages.Render() log = %q, want LogString", got)
+			}
+		})
+	}
+}
+
+func TestPages_Render_Log5xxBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		code    Status
+		want    string
+	}{
+		{
+			"Disabled",
+			false,
+			http.StatusInternalServerError,
+			"",
+		},
+		{
+			"Client error",
+			true,
+			http.StatusNotFound,
+			"",
+		},
+		{
+			"Server error",
+			true,
+			http.StatusBadGateway,
+			"ehtml: rendered 502 Bad Gateway: Foo bar (user 42):\n502 page\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &Pages{
+				Tmpl:       template.Must(template.New("error").Parse("{{ .Status.Int }} page")),
+				RedactFrom: -1,
+				Log5xxBody: tt.enabled,
+				ErrorLog:   log.New(&buf, "", 0),
+			}
+			d := &logData{Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+				Msg:  "Foo bar",
+			}}
+
+			if err := p.Render(httptest.NewRecorder(), d); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Pages.Render() log = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_OnRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		render  func(p *Pages, w http.ResponseWriter, dp Provider) error
+		wantErr bool
+	}{
+		{
+			"Render",
+			"{{ .Message }}",
+			(*Pages).Render,
+			false,
+		},
+		{
+			"Template error",
+			"{{ .Foo }}",
+			(*Pages).Render,
+			true,
+		},
+		{
+			"RenderProblem",
+			"{{ .Message }}",
+			(*Pages).RenderProblem,
+			false,
+		},
+		{
+			"RenderMultipart",
+			"{{ .Message }}",
+			func(p *Pages, w http.ResponseWriter, dp Provider) error {
+				return p.RenderMultipart(w, dp, dp.Request())
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				calls  int
+				gotDp  Provider
+				gotErr error
+			)
+			p := &Pages{
+				Tmpl: template.Must(template.New("error").Parse(tt.tmpl)),
+				OnRender: func(dp Provider, err error) {
+					calls++
+					gotDp, gotErr = dp, err
+				},
+			}
+			d := &Data{
+				Req:  httptest.NewRequest(http.MethodGet, "/", nil),
+				Code: http.StatusNotFound,
+				Msg:  "Not here",
+			}
+
+			err := tt.render(p, httptest.NewRecorder(), d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pages.Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if calls != 1 {
+				t.Fatalf("OnRender called %d times, want 1", calls)
+			}
+			if gotDp != d {
+				t.Errorf("OnRender dp = %v, want %v", gotDp, d)
+			}
+			if gotErr != err {
+				t.Errorf("OnRender err = %v, want %v", gotErr, err)
+			}
+		})
+	}
+}
+
+type observation struct {
+	status Status
+	name   string
+	err    error
+}
+
+type recordObserver []observation
+
+func (o *recordObserver) Observed(status Status, templateName string, duration time.Duration, renderErr error) {
+	*o = append(*o, observation{status, templateName, renderErr})
+}
+
+func TestPages_Observer(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`
+		{{- define "404" }}Not found{{ end -}}
+		{{- define "5xx" }}Server error{{ end -}}
+		{{- define "418" }}{{ .Foo }}{{ end -}}
+		Error`))
+
+	tests := []struct {
+		name    string
+		tmpl    *template.Template
+		code    Status
+		want    string
+		wantErr bool
+	}{
+		{"Exact", tmpl, http.StatusNotFound, "404", false},
+		{"Class", tmpl, http.StatusBadGateway, "5xx", false},
+		{"Generic", tmpl, http.StatusGone, "error", false},
+		{"Default", nil, http.StatusGone, DefaultTmplName, false},
+		{"Template error", tmpl, http.StatusTeapot, "418", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o recordObserver
+			p := &Pages{Tmpl: tt.tmpl, Observer: &o}
+
+			err := p.Render(httptest.NewRecorder(), &Data{Code: tt.code})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pages.Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := recordObserver{{tt.code, tt.want, err}}
+			if !reflect.DeepEqual(o, want) {
+				t.Errorf("Observer got %v, want %v", o, want)
+			}
+		})
+	}
+}
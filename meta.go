@@ -0,0 +1,46 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "net/http"
+
+// ResponseMeta holds the per-response customizations of a page in one place.
+type ResponseMeta struct {
+	// Headers are sent with the page, like those of a HeaderProvider.
+	Headers http.Header
+	// StatusOverride, when non-zero, is written to the client instead of Status(),
+	// like the status of a HTTPStatuser.
+	StatusOverride Status
+}
+
+// MetaProvider can optionally be implemented by a Provider,
+// to customize the response with a ResponseMeta. Meta may return nil.
+// It combines HeaderProvider and HTTPStatuser, for Providers populated in several places,
+// such as middleware. When a Provider implements those as well, the headers from Meta
+// are set after those of Headers(), and StatusOverride takes precedence over HTTPStatus().
+type MetaProvider interface {
+	Meta() *ResponseMeta
+}
+
+// metaOf returns the ResponseMeta of dp, or nil.
+func metaOf(dp Provider) *ResponseMeta {
+	if mp, ok := optional[MetaProvider](dp); ok {
+		return mp.Meta()
+	}
+	return nil
+}
+
+// providerHeaders returns the headers of dp,
+// from HeaderProvider and MetaProvider in that order.
+func providerHeaders(dp Provider) []http.Header {
+	var hs []http.Header
+	if hp, ok := optional[HeaderProvider](dp); ok {
+		hs = append(hs, hp.Headers())
+	}
+	if m := metaOf(dp); m != nil && m.Headers != nil {
+		hs = append(hs, m.Headers)
+	}
+	return hs
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type metaData struct {
+	Data
+	meta *ResponseMeta
+}
+
+func (d *metaData) Meta() *ResponseMeta { return d.meta }
+
+type metaStatusData struct {
+	metaData
+}
+
+func (d *metaStatusData) HTTPStatus() Status { return http.StatusAccepted }
+
+func TestPages_Render_Meta(t *testing.T) {
+	tests := []struct {
+		name     string
+		dp       Provider
+		wantCode int
+		wantHdr  http.Header
+	}{
+		{
+			"Nil",
+			&metaData{Data: Data{Code: http.StatusNotFound}},
+			http.StatusNotFound,
+			http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		},
+		{
+			"Headers",
+			&metaData{
+				Data: Data{Code: http.StatusNotFound},
+				meta: &ResponseMeta{Headers: http.Header{"X-Foo": {"bar"}}},
+			},
+			http.StatusNotFound,
+			http.Header{"X-Foo": {"bar"}, "Content-Type": {"text/html; charset=utf-8"}},
+		},
+		{
+			"Content-Type",
+			&metaData{
+				Data: Data{Code: http.StatusNotFound},
+				meta: &ResponseMeta{Headers: http.Header{"Content-Type": {"text/html; charset=iso-8859-1"}}},
+			},
+			http.StatusNotFound,
+			http.Header{"Content-Type": {"text/html; charset=iso-8859-1"}},
+		},
+		{
+			"After HeaderProvider",
+			&metaData{
+				Data: Data{Code: http.StatusNotFound, Hdr: http.Header{"X-Foo": {"data"}, "X-Bar": {"data"}}},
+				meta: &ResponseMeta{Headers: http.Header{"X-Foo": {"meta"}}},
+			},
+			http.StatusNotFound,
+			http.Header{"X-Foo": {"meta"}, "X-Bar": {"data"}},
+		},
+		{
+			"StatusOverride",
+			&metaData{
+				Data: Data{Code: http.StatusInternalServerError},
+				meta: &ResponseMeta{StatusOverride: http.StatusOK},
+			},
+			http.StatusOK,
+			nil,
+		},
+		{
+			"StatusOverride over HTTPStatuser",
+			&metaStatusData{metaData{
+				Data: Data{Code: http.StatusInternalServerError},
+				meta: &ResponseMeta{StatusOverride: http.StatusOK},
+			}},
+			http.StatusOK,
+			nil,
+		},
+		{
+			"HTTPStatuser",
+			&metaStatusData{metaData{
+				Data: Data{Code: http.StatusInternalServerError},
+				meta: &ResponseMeta{},
+			}},
+			http.StatusAccepted,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }}"))}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, tt.dp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			for k := range tt.wantHdr {
+				if got, want := w.Header().Get(k), tt.wantHdr.Get(k); got != want {
+					t.Errorf("Pages.Render() header %s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
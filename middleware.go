@@ -0,0 +1,231 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// interceptor is a http.ResponseWriter which holds back the statuses
+// for which intercept returns true, so that a page can be rendered instead.
+// When discard is set, the body of an intercepted response is discarded.
+// Otherwise, an intercepted response with a body is passed through
+// and only one without body is replaced by a page.
+type interceptor struct {
+	http.ResponseWriter
+	intercept func(code int) bool
+	discard   bool
+
+	// code is the intercepted status, 0 if none.
+	code        int
+	wroteHeader bool
+}
+
+func (ic *interceptor) WriteHeader(code int) {
+	if ic.wroteHeader || ic.code != 0 {
+		return
+	}
+	// Informational statuses, such as 103 Early Hints, precede the final status.
+	if informational(code) {
+		ic.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if ic.intercept(code) {
+		ic.code = code
+		return
+	}
+
+	ic.wroteHeader = true
+	ic.ResponseWriter.WriteHeader(code)
+}
+
+// informational reports whether code is a 1xx status which is not final.
+// 101 Switching Protocols is final, as the connection is handed over.
+func informational(code int) bool {
+	return code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+}
+
+func (ic *interceptor) Write(b []byte) (int, error) {
+	if !ic.wroteHeader && ic.code == 0 {
+		ic.WriteHeader(http.StatusOK)
+	}
+	if ic.code != 0 {
+		if ic.discard || len(b) == 0 {
+			return len(b), nil
+		}
+
+		// The handler wrote its own body, pass it through.
+		ic.wroteHeader = true
+		ic.ResponseWriter.WriteHeader(ic.code)
+		ic.code = 0
+	}
+	return ic.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (ic *interceptor) Unwrap() http.ResponseWriter { return ic.ResponseWriter }
+
+// HeaderWritten implements HeaderWriter.
+func (ic *interceptor) HeaderWritten() bool { return ic.wroteHeader }
+
+// Flush implements http.Flusher, if the underlying ResponseWriter supports it.
+// A held back status is written first, as the handler streams its own body.
+func (ic *interceptor) Flush() {
+	if ic.code != 0 {
+		ic.ResponseWriter.WriteHeader(ic.code)
+		ic.code = 0
+	}
+	ic.wroteHeader = true
+	http.NewResponseController(ic.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, if the underlying ResponseWriter supports it.
+// No page is rendered for a hijacked connection.
+func (ic *interceptor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(ic.ResponseWriter).Hijack()
+	if err == nil {
+		ic.wroteHeader, ic.code = true, 0
+	}
+	return conn, brw, err
+}
+
+// bypass stops intercepting, as a Render method writes the response itself.
+func (ic *interceptor) bypass() {
+	ic.intercept = func(int) bool { return false }
+	ic.code = 0
+}
+
+// HeaderWriter can optionally be implemented by a http.ResponseWriter,
+// to report whether the status and headers were already written.
+// The Render methods then return ErrHeadersSent instead of writing a second response.
+// ResponseWriters wrapped by Middleware implement it.
+// Wrapping ResponseWriters are searched through their Unwrap method.
+type HeaderWriter interface {
+	HeaderWritten() bool
+}
+
+// headersSent reports whether w, or a ResponseWriter it wraps, is a HeaderWriter
+// which already wrote the headers.
+func headersSent(w http.ResponseWriter) bool {
+	for {
+		if hw, ok := w.(HeaderWriter); ok {
+			return hw.HeaderWritten()
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// bypassIntercept makes the interceptors among w and the ResponseWriters it wraps
+// pass the response through, as a page is rendered to w.
+// Otherwise, Middleware would render the page again for bodiless responses, such as for HEAD.
+func bypassIntercept(w http.ResponseWriter) {
+	for {
+		if ic, ok := w.(*interceptor); ok {
+			ic.bypass()
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// render the page for the intercepted status, if any.
+func (ic *interceptor) render(p *Pages, r *http.Request) {
+	if ic.code == 0 {
+		return
+	}
+
+	// Headers for the intercepted body.
+	h := ic.ResponseWriter.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+
+	// Errors are logged to ErrorLog by Render.
+	p.Handle(ic.ResponseWriter, r, Status(ic.code), "", nil)
+}
+
+// HandlerFunc returns a handler which renders the page for code,
+// with the request it serves. For example, for a router:
+//
+//	rtr.NotFoundHandler = p.HandlerFunc(http.StatusNotFound, "")
+//
+// If msg is empty, the status text is used as message.
+// Errors are logged to ErrorLog by Render.
+func (p *Pages) HandlerFunc(code Status, msg string) http.HandlerFunc {
+	if msg == "" {
+		msg = code.String()
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p.Handle(w, r, code, msg, nil)
+	}
+}
+
+// MaintenanceHandler returns a handler which renders the 503 page for every request,
+// for planned downtime. Like any 503, it uses the "5xx" or "error" template
+// when there is no "503" template.
+// Retry-After is set to retryAfter, rounded to seconds, when positive.
+// The message is empty, so an entry from DefaultMessages is shown, if set.
+func (p *Pages) MaintenanceHandler(retryAfter time.Duration) http.Handler {
+	var hdr http.Header
+	if retryAfter > 0 {
+		hdr = http.Header{"Retry-After": {strconv.Itoa(int(retryAfter.Round(time.Second) / time.Second))}}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := NewData(r, http.StatusServiceUnavailable, "")
+		d.Hdr = hdr
+
+		// Errors are logged to ErrorLog by Render.
+		p.Render(w, d)
+	})
+}
+
+// FileServer returns a handler that serves HTTP requests
+// with the contents of the file system rooted at root, like http.FileServer.
+// Requests for missing files are served with the 404 page from p,
+// instead of the plain text "404 page not found".
+func FileServer(p *Pages, root http.FileSystem) http.Handler {
+	fs := http.FileServer(root)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ic := &interceptor{
+			ResponseWriter: w,
+			intercept:      func(code int) bool { return code == http.StatusNotFound },
+			discard:        true,
+		}
+
+		fs.ServeHTTP(ic, r)
+		ic.render(p, r)
+	})
+}
+
+// Middleware renders the error page when next responds with a 4xx or 5xx status
+// without writing a body.
+// Responses with a body, like from http.Error, are passed through untouched.
+func (p *Pages) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ic := &interceptor{
+			ResponseWriter: w,
+			intercept:      func(code int) bool { return code >= http.StatusBadRequest },
+		}
+
+		next.ServeHTTP(ic, r)
+		ic.render(p, r)
+	})
+}
@@ -0,0 +1,342 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFileServer(t *testing.T) {
+	h := FileServer(&Pages{Tmpl: testTmpl}, http.Dir("testdata/templates"))
+
+	tests := []struct {
+		name            string
+		path            string
+		wantCode        int
+		want            string
+		wantContentType string
+	}{
+		{
+			"Existing file",
+			"/notes.txt",
+			http.StatusOK,
+			"Not a page, this file is a partial.\n",
+			"text/plain; charset=utf-8",
+		},
+		{
+			"Missing file",
+			"/foo.html",
+			http.StatusNotFound,
+			"404 template",
+			"text/html; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com"+tt.path, nil))
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("FileServer() status = %v, want %v", resp.StatusCode, tt.wantCode)
+			}
+			if got := string(body); got != tt.want {
+				t.Errorf("FileServer() = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("FileServer() Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestPages_Middleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		wantCode int
+		want     string
+	}{
+		{
+			"OK",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Hello"))
+			},
+			http.StatusOK,
+			"Hello",
+		},
+		{
+			"Redirect",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusFound)
+			},
+			http.StatusFound,
+			"",
+		},
+		{
+			"Error without body",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			http.StatusNotFound,
+			"404 template",
+		},
+		{
+			"Empty write",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write(nil)
+			},
+			http.StatusNotFound,
+			"404 template",
+		},
+		{
+			"Error with body",
+			func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Not here", http.StatusNotFound)
+			},
+			http.StatusNotFound,
+			"Not here\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: testTmpl}
+			h := p.Middleware(tt.handler)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Middleware() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Middleware() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Middleware_Render(t *testing.T) {
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		t.Run(method, func(t *testing.T) {
+			var calls int
+			p := &Pages{Tmpl: testTmpl, OnRender: func(dp Provider, err error) { calls++ }}
+			h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p.Render(w, &Data{Req: r, Code: http.StatusNotFound, Msg: "No such user"})
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(method, "http://example.com/foo", nil))
+
+			if calls != 1 {
+				t.Errorf("Pages.Middleware() rendered %d times, want 1", calls)
+			}
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Pages.Middleware() status = %v, want %v", w.Code, http.StatusNotFound)
+			}
+			if got, want := w.Header().Get("Content-Length"), strconv.Itoa(len("404 template")); got != want {
+				t.Errorf("Pages.Middleware() Content-Length = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPages_Middleware_Flush(t *testing.T) {
+	p := &Pages{Tmpl: testTmpl}
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.(http.Flusher).Flush()
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+	if !w.Flushed {
+		t.Error("Pages.Middleware() did not flush")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Pages.Middleware() status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Body.String(); got != "" {
+		t.Errorf("Pages.Middleware() = %q, want the streamed response", got)
+	}
+}
+
+func TestPages_Middleware_informational(t *testing.T) {
+	p := &Pages{Tmpl: testTmpl}
+
+	srv := httptest.NewServer(p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusNotFound)
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Pages.Middleware() status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+	}
+	if got := string(body); got != "404 template" {
+		t.Errorf("Pages.Middleware() = %q, want %q", got, "404 template")
+	}
+}
+
+func TestPages_Middleware_Hijack(t *testing.T) {
+	p := &Pages{Tmpl: testTmpl}
+
+	srv := httptest.NewServer(p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		brw.Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Pages.Middleware() status = %v, want %v", resp.StatusCode, http.StatusTeapot)
+	}
+}
+
+func TestPages_HandlerFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     Status
+		msg      string
+		wantBody string
+	}{
+		{"Message", http.StatusNotFound, "No such page", "404 No such page /foo"},
+		{"Status text", http.StatusMethodNotAllowed, "", "405 Method Not Allowed /foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }} {{ .Request.URL.Path }}")),
+			}
+			w := httptest.NewRecorder()
+
+			p.HandlerFunc(tt.code, tt.msg)(w, httptest.NewRequest(http.MethodGet, "/foo", nil))
+
+			if w.Code != tt.code.Int() {
+				t.Errorf("Pages.HandlerFunc() status = %v, want %v", w.Code, tt.code)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.HandlerFunc() = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+// unwrapWriter wraps a ResponseWriter, like the writers of other middleware.
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestPages_Render_headersSent(t *testing.T) {
+	tests := []struct {
+		name     string
+		write    bool
+		wrap     bool
+		wantErr  error
+		wantCode int
+		want     string
+	}{
+		{"Not sent", false, false, nil, http.StatusBadRequest, "Generic template"},
+		{"Sent", true, false, ErrHeadersSent, http.StatusOK, "Hello"},
+		{"Sent wrapped", true, true, ErrHeadersSent, http.StatusOK, "Hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: testTmpl}
+
+			var err error
+			h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.write {
+					w.Write([]byte("Hello"))
+				}
+				if tt.wrap {
+					w = &unwrapWriter{w}
+				}
+				err = p.Render(w, &Data{Req: r, Code: http.StatusBadRequest})
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Pages.Render() err = %v, want %v", err, tt.wantErr)
+			}
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_MaintenanceHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		tmpl      string
+		retry     time.Duration
+		want      string
+		wantRetry string
+	}{
+		{"503", `{{ define "503" }}Maintenance{{ end }}{{ define "error" }}error{{ end }}`, 90 * time.Second, "Maintenance", "90"},
+		{"Error", `{{ define "error" }}{{ .Status.Int }} {{ .Message }}{{ end }}`, 1500 * time.Millisecond, "503 ", "2"},
+		{"No retry", `{{ define "503" }}Maintenance{{ end }}`, 0, "Maintenance", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: template.Must(template.New("").Parse(tt.tmpl))}
+			h := p.MaintenanceHandler(tt.retry)
+
+			for _, path := range []string{"/", "/foo/bar"} {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+				if w.Code != http.StatusServiceUnavailable {
+					t.Errorf("MaintenanceHandler() status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+				}
+				if got := w.Body.String(); got != tt.want {
+					t.Errorf("MaintenanceHandler() = %q, want %q", got, tt.want)
+				}
+				if got := w.Header().Get("Retry-After"); got != tt.wantRetry {
+					t.Errorf("MaintenanceHandler() Retry-After = %q, want %q", got, tt.wantRetry)
+				}
+			}
+		})
+	}
+}
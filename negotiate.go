@@ -0,0 +1,267 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats, as used in template names like "404.json".
+const (
+	formatHTML = "html"
+	formatJSON = "json"
+	formatText = "txt"
+)
+
+// formats in order of preference, when equally accepted.
+var formats = []struct {
+	name        string
+	mediaType   string
+	contentType string
+}{
+	{formatHTML, "text/html", "text/html; charset=utf-8"},
+	{formatJSON, "application/json", "application/json"},
+	{formatText, "text/plain", "text/plain; charset=utf-8"},
+}
+
+func contentType(format string) string {
+	for _, f := range formats {
+		if f.name == format {
+			return f.contentType
+		}
+	}
+	return ""
+}
+
+// negotiateFormat returns the format best matching the Accept header.
+// The most specific media range determines the quality of a format.
+// It returns formatHTML when nothing else is preferred.
+func negotiateFormat(accept string) string {
+	if accept == "" {
+		return formatHTML
+	}
+
+	type match struct {
+		specificity int
+		q           float64
+	}
+	matches := make([]match, len(formats))
+
+	for _, mr := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mr))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		for i, f := range formats {
+			var specificity int
+
+			switch mediaType {
+			case f.mediaType:
+				specificity = 3
+			case f.mediaType[:strings.IndexByte(f.mediaType, '/')] + "/*":
+				specificity = 2
+			case "*/*":
+				specificity = 1
+			default:
+				continue
+			}
+
+			if specificity > matches[i].specificity {
+				matches[i] = match{specificity, q}
+			}
+		}
+	}
+
+	// Explicit media types win over wildcards of equal quality,
+	// so "text/plain, */*" is served text.
+	best, bestMatch := formatHTML, match{}
+	for i, f := range formats {
+		m := matches[i]
+		if m.q > bestMatch.q || (m.q > 0 && m.q == bestMatch.q && m.specificity > bestMatch.specificity) {
+			best, bestMatch = f.name, m
+		}
+	}
+	return best
+}
+
+// format returns the format to render for r.
+// It is always formatHTML if DisableNegotiation is set.
+// See varyAccept for the Vary header.
+func (p *Pages) format(r *http.Request) string {
+	if p.DisableNegotiation || r == nil {
+		return formatHTML
+	}
+	return negotiateFormat(r.Header.Get("Accept"))
+}
+
+// varyAccept adds Accept to the Vary header of h when the format depends on it,
+// so shared caches don't serve JSON to browsers.
+func (p *Pages) varyAccept(h http.Header, r *http.Request) {
+	if !p.DisableNegotiation && r != nil {
+		addVary(h, "Accept")
+	}
+}
+
+// executeFormat executes the template for dp in format into buf and returns its name.
+// Formats other than formatHTML are looked up in TextTmpl
+// and rendered with their built-in default if not found.
+func (p *Pages) executeFormat(buf *bytes.Buffer, set *template.Template, format string, dp Provider, data interface{}) (string, error) {
+	if format == formatHTML {
+		return p.execute(buf, set, dp, data)
+	}
+
+	var w io.Writer = buf
+	if p.MaxBufferBytes > 0 {
+		w = &limitWriter{buf, p.MaxBufferBytes}
+	}
+
+	if p.TextTmpl != nil {
+		if tmpl := lookupFormat(p.TextTmpl, dp.Status(), format); tmpl != nil {
+			if err := tmpl.Execute(w, data); err != nil {
+				return tmpl.Name(), wrapKind(ErrTemplateExec, fmt.Errorf("ehtml Render template: %w", err))
+			}
+			return tmpl.Name(), nil
+		}
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if format == formatJSON {
+		b, err = jsonBody(dp)
+	} else {
+		b = []byte(dp.String() + "\n")
+	}
+	if err == nil {
+		_, err = w.Write(b)
+	}
+	if err != nil {
+		return DefaultTmplName, wrapKind(ErrTemplateExec, fmt.Errorf("ehtml Render %s: %w", format, err))
+	}
+	return DefaultTmplName, nil
+}
+
+// jsonError is the JSON representation of a Provider.
+type jsonError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+func jsonBody(dp Provider) ([]byte, error) {
+	s := dp.Status()
+
+	return json.Marshal(jsonError{
+		Status:  s.Int(),
+		Message: dp.Message(),
+		Error:   fmt.Sprintf("%d %s", s, s),
+	})
+}
+
+// RenderText renders the plain text page for dp, regardless of the Accept header,
+// for clients such as terminals.
+// The template is looked up in TextTmpl like a negotiated "txt" format, eg: "404.txt",
+// falling back to the String() of the Provider.
+// All other options of Pages apply as for Render.
+func (p *Pages) RenderText(w http.ResponseWriter, dp Provider) error {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	set, lang := p.templateSet(dp.Request())
+	name, err := p.render(context.Background(), cw, set, lang, formatText, dp)
+	return p.rendered(dp, name, start, cw.n, err)
+}
+
+// RenderMultipart renders a "multipart/mixed" response, with the html page
+// and its JSON representation as parts, for debugging tools which need both.
+// It needs to be enabled with Pages.EnableMultipart and
+// is only used when the Accept header of r includes "multipart/mixed".
+// Otherwise, it is the same as Render.
+// When enabled, Accept is added to the Vary header in both cases.
+func (p *Pages) RenderMultipart(w http.ResponseWriter, dp Provider, r *http.Request) error {
+	if !p.EnableMultipart || r == nil || !strings.Contains(r.Header.Get("Accept"), "multipart/mixed") {
+		if p.EnableMultipart && r != nil {
+			// Other Accept headers would get the multipart response.
+			addVary(w.Header(), "Accept")
+		}
+		return p.Render(w, dp)
+	}
+
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	set, lang := p.templateSet(r)
+	name, err := p.renderMultipart(cw, set, lang, dp)
+	return p.rendered(dp, name, start, cw.n, err)
+}
+
+func (p *Pages) renderMultipart(w http.ResponseWriter, set *template.Template, lang string, dp Provider) (string, error) {
+	if headersSent(w) {
+		return "", ErrHeadersSent
+	}
+	bypassIntercept(w)
+	p.commonHeaders(w.Header(), lang, dp)
+	// The multipart response is negotiated, also with DisableNegotiation.
+	addVary(w.Header(), "Accept")
+	data := p.pageHeaders(w.Header(), dp, lang)
+
+	page := buffers.Get()
+	defer buffers.Put(page)
+
+	name, err := p.execute(page, set, dp, data)
+	if err != nil {
+		return name, p.renderError(w, dp, data, err)
+	}
+
+	js, err := jsonBody(dp)
+	if err != nil {
+		return name, p.renderError(w, dp, data, fmt.Errorf("ehtml RenderMultipart json: %w", err))
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/html; charset=utf-8", page.Bytes()},
+		{"application/json", js},
+	} {
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		pw.Write(part.body)
+	}
+	mw.Close()
+
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+
+	if err := p.writeBody(w, dp, &buf); err != nil {
+		return name, wrapKind(ErrClientWrite, fmt.Errorf("ehtml RenderMultipart, write to client: %w", err))
+	}
+	return name, nil
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	texttemplate "text/template"
+)
+
+func Test_negotiateFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"Empty", "", formatHTML},
+		{"HTML", "text/html", formatHTML},
+		{"JSON", "application/json", formatJSON},
+		{"Text", "text/plain", formatText},
+		{"Any", "*/*", formatHTML},
+		{"Browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatHTML},
+		{"Quality", "text/html;q=0.5, application/json", formatJSON},
+		{"Wildcard subtype", "text/*", formatHTML},
+		{"Specific over wildcard", "text/*, text/html;q=0.1", formatText},
+		{"Excluded", "application/json;q=0, */*", formatHTML},
+		{"Unknown", "image/png", formatHTML},
+		{"Invalid quality", "application/json;q=high", formatHTML},
+		{"Explicit over any", "text/plain, */*", formatText},
+		{"Axios", "application/json, text/plain, */*", formatJSON},
+		{"Any preferred", "*/*, text/plain;q=0.5", formatHTML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateFormat(tt.accept); got != tt.want {
+				t.Errorf("negotiateFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_Negotiate(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(
+		`{{ define "404.html" }}404 html{{ end }}{{ define "error" }}{{ .Message }}{{ end }}`,
+	))
+	textTmpl := texttemplate.Must(texttemplate.New("error.txt").Parse(`{{ .Status.Int }} '{{ .Message }}'`))
+
+	tests := []struct {
+		name     string
+		disabled bool
+		textTmpl *texttemplate.Template
+		accept   string
+		code     Status
+		want     string
+		wantType string
+	}{
+		{
+			"Disabled",
+			true,
+			textTmpl,
+			"application/json",
+			http.StatusNotFound,
+			"Foo&#39;s",
+			"",
+		},
+		{
+			"Empty",
+			false,
+			textTmpl,
+			"",
+			http.StatusBadRequest,
+			"Foo&#39;s",
+			"",
+		},
+		{
+			"HTML format template",
+			false,
+			nil,
+			"text/html",
+			http.StatusNotFound,
+			"404 html",
+			"",
+		},
+		{
+			"HTML lookup scheme",
+			false,
+			nil,
+			"text/html",
+			http.StatusBadRequest,
+			"Foo&#39;s",
+			"",
+		},
+		{
+			"JSON default",
+			false,
+			textTmpl,
+			"application/json",
+			http.StatusNotFound,
+			`{"status":404,"message":"Foo's","error":"404 Not Found"}`,
+			"application/json",
+		},
+		{
+			"Text template",
+			false,
+			textTmpl,
+			"text/plain",
+			http.StatusNotFound,
+			"404 'Foo's'",
+			"text/plain; charset=utf-8",
+		},
+		{
+			"Text default",
+			false,
+			nil,
+			"text/plain",
+			http.StatusNotFound,
+			"404 Not Found: Foo's\n",
+			"text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:               tmpl,
+				DisableNegotiation: tt.disabled,
+				TextTmpl:           tt.textTmpl,
+			}
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, &Data{Req: r, Code: tt.code, Msg: "Foo's"}); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+			if got := varies(resp.Header, "Accept"); got == tt.disabled {
+				t.Errorf("Pages.Render() Vary Accept = %v, want %v", got, !tt.disabled)
+			}
+			if tt.wantType != "" {
+				if got := resp.Header.Get("Content-Type"); got != tt.wantType {
+					t.Errorf("Pages.Render() Content-Type = %v, want %v", got, tt.wantType)
+				}
+			}
+		})
+	}
+}
+
+func Test_jsonBody(t *testing.T) {
+	d := &Data{Code: http.StatusNotFound, Msg: "Foo bar"}
+
+	got, err := jsonBody(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"status":404,"message":"Foo bar","error":"404 Not Found"}`; string(got) != want {
+		t.Errorf("jsonBody() = %s, want %s", got, want)
+	}
+}
+
+func TestPages_RenderMultipart(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		accept    string
+		wantVary  bool
+		wantParts []string
+	}{
+		{
+			"Disabled",
+			false,
+			"multipart/mixed",
+			false,
+			nil,
+		},
+		{
+			"Not accepted",
+			true,
+			"text/html",
+			true,
+			nil,
+		},
+		{
+			"Multipart",
+			true,
+			"multipart/mixed, text/html;q=0.9",
+			true,
+			[]string{
+				"404 template",
+				`{"status":404,"message":"Foo bar","error":"404 Not Found"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:               testTmpl,
+				EnableMultipart:    tt.enabled,
+				DisableNegotiation: true,
+			}
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			r.Header.Set("Accept", tt.accept)
+			d := &Data{Req: r, Code: http.StatusNotFound, Msg: "Foo bar"}
+
+			w := httptest.NewRecorder()
+			if err := p.RenderMultipart(w, d, r); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("Pages.RenderMultipart() status = %v, want %v", resp.StatusCode, http.StatusNotFound)
+			}
+			if got := varies(resp.Header, "Accept"); got != tt.wantVary {
+				t.Errorf("Pages.RenderMultipart() Vary = %v, want Accept %v", resp.Header.Values("Vary"), tt.wantVary)
+			}
+
+			mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+			if mediaType != "multipart/mixed" {
+				if tt.wantParts != nil {
+					t.Fatalf("Pages.RenderMultipart() Content-Type = %v, want multipart/mixed", mediaType)
+				}
+				return
+			}
+
+			var parts []string
+			mr := multipart.NewReader(resp.Body, params["boundary"])
+			for {
+				part, err := mr.NextPart()
+				if err != nil {
+					break
+				}
+				b, _ := ioutil.ReadAll(part)
+				parts = append(parts, string(b))
+			}
+
+			if !reflect.DeepEqual(parts, tt.wantParts) {
+				t.Errorf("Pages.RenderMultipart() parts = %v, want %v", parts, tt.wantParts)
+			}
+		})
+	}
+}
+
+func Test_pageNames(t *testing.T) {
+	tests := []struct {
+		status Status
+		suffix string
+		want   []string
+	}{
+		{http.StatusNotFound, "", []string{"404", "4xx", "error"}},
+		{http.StatusGatewayTimeout, ".txt", []string{"504.txt", "timeout.txt", "5xx.txt", "error.txt"}},
+		{42, ".json", []string{"42.json", "error.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status.toA(), func(t *testing.T) {
+			if got := pageNames(tt.status, tt.suffix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pageNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_RenderText(t *testing.T) {
+	textTmpl := texttemplate.Must(texttemplate.New("404.txt").Parse("Not found: {{ .Message }}"))
+	texttemplate.Must(textTmpl.New("5xx.txt").Parse("Server error <{{ .Status.Int }}>"))
+
+	tests := []struct {
+		name     string
+		textTmpl *texttemplate.Template
+		code     Status
+		want     string
+	}{
+		{"Exact", textTmpl, http.StatusNotFound, "Not found: <b>gone</b>"},
+		{"Class", textTmpl, http.StatusBadGateway, "Server error <502>"},
+		{"Default", textTmpl, http.StatusGone, "410 Gone: <b>gone</b>\n"},
+		{"No TextTmpl", nil, http.StatusNotFound, "404 Not Found: <b>gone</b>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:       template.Must(template.New("error").Parse("html")),
+				TextTmpl:   tt.textTmpl,
+				RedactFrom: -1,
+			}
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", "text/html")
+			w := httptest.NewRecorder()
+
+			if err := p.RenderText(w, &Data{Req: r, Code: tt.code, Msg: "<b>gone</b>"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tt.code.Int() {
+				t.Errorf("Pages.RenderText() status = %v, want %v", w.Code, tt.code)
+			}
+			if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+				t.Errorf("Pages.RenderText() Content-Type = %q, want %q", got, want)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "html/template"
+
+// Option configures Pages created by New.
+type Option func(*Pages)
+
+// New returns Pages configured by opts, applied in order.
+// It is the preferred way to create Pages,
+// although the zero value of Pages remains ready to use.
+func New(opts ...Option) *Pages {
+	p := new(Pages)
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithTemplate sets Pages.Tmpl.
+func WithTemplate(tmpl *template.Template) Option {
+	return func(p *Pages) { p.Tmpl = tmpl }
+}
+
+// WithCharset sets Pages.Charset.
+func WithCharset(charset string) Option {
+	return func(p *Pages) { p.Charset = charset }
+}
+
+// WithDefaultStatus sets Pages.DefaultStatus.
+func WithDefaultStatus(s Status) Option {
+	return func(p *Pages) { p.DefaultStatus = s }
+}
+
+// WithOnRender sets Pages.OnRender.
+func WithOnRender(fn func(dp Provider, err error)) Option {
+	return func(p *Pages) { p.OnRender = fn }
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var rendered Provider
+
+	p := New(
+		WithTemplate(template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Status }}"))),
+		WithCharset("iso-8859-1"),
+		WithDefaultStatus(http.StatusServiceUnavailable),
+		WithOnRender(func(dp Provider, err error) { rendered = dp }),
+	)
+
+	w := httptest.NewRecorder()
+	if err := p.Render(w, &Data{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Pages.Render() status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	if got, want := w.Body.String(), "503 Service Unavailable"; got != want {
+		t.Errorf("Pages.Render() = %q, want %q", got, want)
+	}
+	if got, want := w.Header().Get("Content-Type"), "text/html; charset=iso-8859-1"; got != want {
+		t.Errorf("Pages.Render() Content-Type = %q, want %q", got, want)
+	}
+	if rendered == nil {
+		t.Error("OnRender was not called")
+	}
+}
+
+func TestNew_zero(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := New().Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Pages.Render() status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// AddPartialFunc adds the "partial" func to set and returns it.
+// It must be called before parsing templates using the func into set.
+//
+// `{{ partial "head" . }}` executes the status specific variant of a partial template,
+// such as a different head with a monitoring script on the 500 page.
+// For status 503, the templates "head-503", "head-5xx" and "head" are tried in that order.
+// The data must have a Status() method, which Providers have, for the variants to apply.
+// The output is escaped for the HTML text context, such as the document or an element body.
+// It is an error when none of the templates is defined.
+func AddPartialFunc(set *template.Template) *template.Template {
+	return set.Funcs(template.FuncMap{"partial": partialFunc(set)})
+}
+
+func partialFunc(set *template.Template) func(name string, data interface{}) (template.HTML, error) {
+	return func(name string, data interface{}) (template.HTML, error) {
+		tmpl := lookupNames(set, partialNames(name, data))
+		if tmpl == nil {
+			return "", fmt.Errorf("ehtml partial: no template %q", name)
+		}
+
+		buf := buffers.Get()
+		defer buffers.Put(buf)
+
+		if err := tmpl.Execute(buf, data); err != nil {
+			return "", fmt.Errorf("ehtml partial: %w", err)
+		}
+		return template.HTML(buf.String()), nil
+	}
+}
+
+// partialNames returns the names of the variants of partial name,
+// for the status of data, in order of precedence.
+func partialNames(name string, data interface{}) []string {
+	sp, ok := data.(interface{ Status() Status })
+	if !ok {
+		return []string{name}
+	}
+
+	s := sp.Status()
+	names := []string{name + "-" + s.toA()}
+	if class := s.classTmpl(); class != "" {
+		names = append(names, name+"-"+class)
+	}
+	return append(names, name)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_partialNames(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []string
+	}{
+		{"Provider", &Data{Code: http.StatusServiceUnavailable}, []string{"head-503", "head-5xx", "head"}},
+		{"No class", &Data{Code: 0}, []string{"head-0", "head"}},
+		{"No status", map[string]string{}, []string{"head"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partialNames("head", tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("partialNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPartialFunc(t *testing.T) {
+	set := AddPartialFunc(template.New("error"))
+	template.Must(set.Parse(`{{ partial "head" . }}<p>{{ .Message }}</p>`))
+	template.Must(set.New("head").Parse(`<title>{{ .String }}</title>`))
+	template.Must(set.New("head-5xx").Parse(`<title>Server error</title>`))
+	template.Must(set.New("head-500").Parse(`<script src="/monitor.js"></script><title>{{ .Status }}</title>`))
+
+	tests := []struct {
+		name    string
+		code    Status
+		msg     string
+		want    string
+		wantErr bool
+	}{
+		{"Code", http.StatusInternalServerError, "", `<script src="/monitor.js"></script><title>Internal Server Error</title><p></p>`, false},
+		{"Class", http.StatusBadGateway, "", `<title>Server error</title><p></p>`, false},
+		{"Generic", http.StatusNotFound, "<b>", `<title>404 Not Found: &lt;b&gt;</title><p>&lt;b&gt;</p>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: set}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, &Data{Code: tt.code, Msg: tt.msg}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Undefined", func(t *testing.T) {
+		set := AddPartialFunc(template.New("error"))
+		template.Must(set.Parse(`{{ partial "foot" . }}`))
+
+		err := (&Pages{Tmpl: set}).Render(httptest.NewRecorder(), &Data{Code: http.StatusNotFound})
+		if err == nil || !strings.Contains(err.Error(), `ehtml partial: no template "foot"`) {
+			t.Errorf("Pages.Render() err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// problem is the RFC 7807 problem details object.
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+func problemBody(dp Provider) ([]byte, error) {
+	s := httpStatus(dp)
+
+	pb := problem{
+		Type:   "about:blank",
+		Title:  s.String(),
+		Status: s.Int(),
+		Detail: dp.Message(),
+	}
+	if r := dp.Request(); r != nil && r.URL != nil {
+		pb.Instance = r.URL.Path
+	}
+
+	return json.Marshal(pb)
+}
+
+// RenderProblem renders dp as "application/problem+json", as defined by RFC 7807,
+// for API clients which expect standard error bodies.
+// The type is "about:blank", the title is the status text and the detail is the message.
+// The instance is the request path, if dp has a Request.
+// Templates are not used, the other options of Pages apply as for Render.
+func (p *Pages) RenderProblem(w http.ResponseWriter, dp Provider) error {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	err := p.renderProblem(cw, dp)
+	return p.rendered(dp, "", start, cw.n, err)
+}
+
+func (p *Pages) renderProblem(w http.ResponseWriter, dp Provider) error {
+	if headersSent(w) {
+		return ErrHeadersSent
+	}
+	bypassIntercept(w)
+	p.commonHeaders(w.Header(), "", dp)
+
+	b, err := problemBody(dp)
+	if err != nil {
+		return p.renderError(w, dp, p.templateData(dp, "", ""), fmt.Errorf("ehtml RenderProblem json: %w", err))
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+
+	if err := p.writeBody(w, dp, bytes.NewBuffer(b)); err != nil {
+		return wrapKind(ErrClientWrite, fmt.Errorf("ehtml RenderProblem, write to client: %w", err))
+	}
+	return nil
+}
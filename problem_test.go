@@ -0,0 +1,67 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_RenderProblem(t *testing.T) {
+	tests := []struct {
+		name     string
+		dp       Provider
+		wantCode int
+		want     string
+	}{
+		{
+			"Request",
+			&Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo?bar=1", nil),
+				Code: http.StatusNotFound,
+				Msg:  "Foo bar",
+			},
+			http.StatusNotFound,
+			`{"type":"about:blank","title":"Not Found","status":404,"detail":"Foo bar","instance":"/foo"}`,
+		},
+		{
+			"No request",
+			&Data{Code: http.StatusConflict},
+			http.StatusConflict,
+			`{"type":"about:blank","title":"Conflict","status":409}`,
+		},
+		{
+			"Redacted",
+			&Data{Code: http.StatusInternalServerError, Msg: "secret"},
+			http.StatusInternalServerError,
+			`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"` + DefaultRedactedMessage + `"}`,
+		},
+		{
+			"HTTPStatus",
+			&softData{Data{Code: http.StatusInternalServerError}, http.StatusOK},
+			http.StatusOK,
+			`{"type":"about:blank","title":"OK","status":200}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := (&Pages{RedactFrom: DefaultRedactFrom}).RenderProblem(w, tt.dp); err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.RenderProblem() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Pages.RenderProblem() Content-Type = %v, want %v", got, "application/problem+json")
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.RenderProblem() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
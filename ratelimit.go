@@ -0,0 +1,67 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimiter can optionally be implemented by a Provider,
+// to report quota details on a "429 Too Many Requests" page.
+// Render sets the X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset
+// and Retry-After headers, and exposes the values to the template as
+// `.RateLimit.Limit`, `.RateLimit.Remaining`, `.RateLimit.Reset` and `.RateLimit.RetryAfter`.
+// A zero reset time means it is unknown: X-RateLimit-Reset and Retry-After are omitted.
+// It is ignored for all other statuses.
+type RateLimiter interface {
+	// RateLimit returns the request quota, the remaining requests
+	// and the time at which the quota resets.
+	RateLimit() (limit, remaining int, reset time.Time)
+}
+
+// rateLimit is exposed to the templates as `.RateLimit`.
+type rateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// RetryAfter is the number of seconds until Reset.
+	RetryAfter int
+}
+
+func rateLimitOf(dp Provider) *rateLimit {
+	if dp.Status() != http.StatusTooManyRequests {
+		return nil
+	}
+
+	rl, ok := optional[RateLimiter](dp)
+	if !ok {
+		return nil
+	}
+
+	limit, remaining, reset := rl.RateLimit()
+
+	retry := time.Until(reset).Round(time.Second)
+	if retry < 0 {
+		retry = 0
+	}
+
+	return &rateLimit{
+		Limit:      limit,
+		Remaining:  remaining,
+		Reset:      reset,
+		RetryAfter: int(retry / time.Second),
+	}
+}
+
+func (rl *rateLimit) setHeaders(h *headerSetter) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(rl.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+	if !rl.Reset.IsZero() {
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(rl.Reset.Unix(), 10))
+		h.Set("Retry-After", strconv.Itoa(rl.RetryAfter))
+	}
+}
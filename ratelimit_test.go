@@ -0,0 +1,115 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type rateLimitData struct {
+	Data
+	reset time.Time
+}
+
+func (d *rateLimitData) RateLimit() (int, int, time.Time) { return 100, 0, d.reset }
+
+func TestPages_Render_RateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Minute)
+
+	tmpl := template.Must(template.New("error").Parse(
+		`{{ define "429" }}{{ .RateLimit.Remaining }}/{{ .RateLimit.Limit }}{{ end }}{{ define "error" }}{{ .Message }}{{ end }}`,
+	))
+
+	tests := []struct {
+		name        string
+		code        Status
+		reset       time.Time
+		want        string
+		wantHeaders map[string]string
+	}{
+		{
+			"Too many requests",
+			http.StatusTooManyRequests,
+			reset,
+			"0/100",
+			map[string]string{
+				"X-RateLimit-Limit":     "100",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+				"Retry-After":           "60",
+			},
+		},
+		{
+			"Unknown reset",
+			http.StatusTooManyRequests,
+			time.Time{},
+			"0/100",
+			map[string]string{
+				"X-RateLimit-Limit":     "100",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "",
+				"Retry-After":           "",
+			},
+		},
+		{
+			"Other status",
+			http.StatusBadRequest,
+			reset,
+			"Foo bar",
+			map[string]string{
+				"X-RateLimit-Limit":     "",
+				"X-RateLimit-Remaining": "",
+				"X-RateLimit-Reset":     "",
+				"Retry-After":           "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: tmpl}
+			d := &rateLimitData{
+				Data{
+					Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+					Code: tt.code,
+					Msg:  "Foo bar",
+				},
+				tt.reset,
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			body, _ := ioutil.ReadAll(resp.Body)
+
+			if got := string(body); got != tt.want {
+				t.Errorf("Pages.Render() = %v, want %v", got, tt.want)
+			}
+			for k, want := range tt.wantHeaders {
+				if got := resp.Header.Get(k); got != want {
+					t.Errorf("Pages.Render() header %s = %v, want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_rateLimitOf_past(t *testing.T) {
+	d := &rateLimitData{
+		Data{Code: http.StatusTooManyRequests},
+		time.Now().Add(-time.Minute),
+	}
+	if got := rateLimitOf(d).RetryAfter; got != 0 {
+		t.Errorf("rateLimitOf().RetryAfter = %v, want %v", got, 0)
+	}
+}
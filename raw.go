@@ -0,0 +1,95 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// rawWriter is a http.ResponseWriter which writes a HTTP/1.1 response,
+// including the status line, to an underlying writer.
+type rawWriter struct {
+	w           *bufio.Writer
+	header      http.Header
+	wroteHeader bool
+}
+
+func (rw *rawWriter) Header() http.Header { return rw.header }
+
+// WriteHeader writes the status line, using Status.String() as reason phrase,
+// followed by the headers.
+func (rw *rawWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	// Without Content-Length, the body ends when the connection is closed.
+	rw.header.Set("Connection", "close")
+
+	fmt.Fprintf(rw.w, "HTTP/1.1 %03d %s\r\n", code, Status(code))
+	rw.header.Write(rw.w)
+	rw.w.WriteString("\r\n")
+}
+
+func (rw *rawWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.w.Write(b)
+}
+
+// RenderRaw renders the page like Render, but writes a complete HTTP/1.1 response
+// to w, including the status line.
+// The reason phrase is taken from Status.String(),
+// so texts registered with RegisterStatusText end up on the wire.
+// The response is sent with "Connection: close" and the connection
+// should be closed after RenderRaw returns.
+func (p *Pages) RenderRaw(w io.Writer, dp Provider) error {
+	return p.renderRaw(w, make(http.Header), dp)
+}
+
+func (p *Pages) renderRaw(w io.Writer, header http.Header, dp Provider) error {
+	rw := &rawWriter{
+		w:      bufio.NewWriter(w),
+		header: header,
+	}
+
+	// Flush on errors as well, for RenderError or the FallbackTmpl page.
+	err := p.Render(rw, dp)
+	if ferr := rw.w.Flush(); ferr != nil {
+		err = errors.Join(err, wrapKind(ErrClientWrite, fmt.Errorf("ehtml RenderRaw, write to client: %w", ferr)))
+	}
+	return err
+}
+
+// RenderHijack hijacks the connection from w and renders the page to it using RenderRaw.
+// Headers already set on w are sent along.
+// The connection is closed before RenderHijack returns.
+//
+// The Go http server always writes the standard reason phrase,
+// hijacking is the only way to send a registered status text.
+// w must implement http.Hijacker, which is only the case for HTTP/1.x connections.
+// Otherwise an error wrapping http.ErrNotSupported is returned and nothing is written.
+func (p *Pages) RenderHijack(w http.ResponseWriter, dp Provider) error {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("ehtml RenderHijack: %w", http.ErrNotSupported)
+	}
+
+	header := w.Header().Clone()
+
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return fmt.Errorf("ehtml RenderHijack: %w", err)
+	}
+	defer conn.Close()
+
+	return p.renderRaw(conn, header, dp)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testStatus Status = 599
+
+func registerTestStatus(t *testing.T) {
+	RegisterStatusText(testStatus, "Test Status")
+	t.Cleanup(func() {
+		statusTexts.Lock()
+		delete(statusTexts.m, testStatus)
+		statusTexts.Unlock()
+	})
+}
+
+func TestRegisterStatusText(t *testing.T) {
+	registerTestStatus(t)
+
+	if got := testStatus.String(); got != "Test Status" {
+		t.Errorf("Status.String() = %v, want %v", got, "Test Status")
+	}
+}
+
+func TestPages_RenderRaw(t *testing.T) {
+	registerTestStatus(t)
+
+	p := &Pages{Tmpl: testTmpl}
+	d := &Data{
+		Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+		Code: testStatus,
+		Msg:  "Foo bar",
+	}
+
+	var buf bytes.Buffer
+	if err := p.RenderRaw(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(&buf), d.Req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if want := "599 Test Status"; resp.Status != want {
+		t.Errorf("Pages.RenderRaw() status = %v, want %v", resp.Status, want)
+	}
+	if want := "Generic template"; string(body) != want {
+		t.Errorf("Pages.RenderRaw() = %v, want %v", string(body), want)
+	}
+}
+
+func TestPages_RenderRaw_WriteError(t *testing.T) {
+	p := &Pages{}
+	d := &Data{Code: http.StatusTeapot}
+
+	if err := p.RenderRaw(errorWriter{}, d); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Pages.RenderRaw() error = %v, wantErr %v", err, io.ErrClosedPipe)
+	}
+}
+
+func TestPages_RenderRaw_RenderError(t *testing.T) {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Foo }}"))}
+	d := &Data{Req: httptest.NewRequest("GET", "http://example.com/foo", nil), Code: http.StatusNotFound}
+
+	var buf bytes.Buffer
+	if err := p.RenderRaw(&buf, d); !errors.Is(err, ErrTemplateExec) {
+		t.Fatalf("Pages.RenderRaw() error = %v, want %v", err, ErrTemplateExec)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(&buf), d.Req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Pages.RenderRaw() status = %v, want %v", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if want := fmt.Sprintf(RenderError, d.String()); string(body) != want {
+		t.Errorf("Pages.RenderRaw() = %q, want %q", body, want)
+	}
+}
+
+func TestPages_RenderHijack(t *testing.T) {
+	registerTestStatus(t)
+
+	p := &Pages{Tmpl: testTmpl}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Foo", "Bar")
+		if err := p.RenderHijack(w, &Data{Req: r, Code: testStatus}); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if want := "599 Test Status"; resp.Status != want {
+		t.Errorf("Pages.RenderHijack() status = %v, want %v", resp.Status, want)
+	}
+	if got := resp.Header.Get("X-Foo"); got != "Bar" {
+		t.Errorf("Pages.RenderHijack() header = %v, want %v", got, "Bar")
+	}
+}
+
+func TestPages_RenderHijack_NotSupported(t *testing.T) {
+	p := &Pages{}
+	w := httptest.NewRecorder()
+
+	if err := p.RenderHijack(w, &Data{Code: http.StatusTeapot}); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Pages.RenderHijack() error = %v, wantErr %v", err, http.ErrNotSupported)
+	}
+}
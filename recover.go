@@ -0,0 +1,138 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoveredMessage formats a value returned by recover() as message.
+func recoveredMessage(rec interface{}) string {
+	switch v := rec.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// RenderRecovered renders a "500 Internal Server Error" page for rec,
+// the value returned by recover(), at a manual recover site:
+//
+//	defer func() {
+//		if rec := recover(); rec != nil {
+//			p.RenderRecovered(w, r, rec)
+//		}
+//	}()
+//
+// The recovered value is used as message.
+// When DevMode is enabled, the stack is captured in Data.Stack.
+// RenderRecovered must be called from the deferred function for the stack to be meaningful.
+func (p *Pages) RenderRecovered(w http.ResponseWriter, r *http.Request, rec interface{}) error {
+	var stack []byte
+	if p.DevMode {
+		stack = debug.Stack()
+	}
+	return p.renderRecovered(w, r, rec, stack)
+}
+
+func (p *Pages) renderRecovered(w http.ResponseWriter, r *http.Request, rec interface{}, stack []byte) error {
+	d := &Data{
+		Req:  r,
+		Code: http.StatusInternalServerError,
+		Msg:  recoveredMessage(rec),
+	}
+	if p.DevMode {
+		d.Stack = string(stack)
+	}
+
+	return p.handle(w, d, nil)
+}
+
+// headerTracker is a http.ResponseWriter which records
+// whether the header was sent.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (ht *headerTracker) WriteHeader(code int) {
+	// Informational statuses, such as 103 Early Hints, don't send the final header.
+	if !informational(code) {
+		ht.wroteHeader = true
+	}
+	ht.ResponseWriter.WriteHeader(code)
+}
+
+func (ht *headerTracker) Write(b []byte) (int, error) {
+	ht.wroteHeader = true
+	return ht.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, if the underlying ResponseWriter supports it.
+func (ht *headerTracker) Flush() {
+	ht.wroteHeader = true
+	http.NewResponseController(ht.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker, if the underlying ResponseWriter supports it.
+// A panic after hijacking aborts the handler, as the response can't be written.
+func (ht *headerTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(ht.ResponseWriter).Hijack()
+	if err == nil {
+		ht.wroteHeader = true
+	}
+	return conn, brw, err
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (ht *headerTracker) Unwrap() http.ResponseWriter { return ht.ResponseWriter }
+
+// Recover renders the 500 page when next panics,
+// with a message derived from the recovered value, like RenderRecovered.
+// OnPanic is called first, if set.
+//
+// If next already sent the header or part of the body before panicking,
+// nothing more is written and the response is aborted with http.ErrAbortHandler,
+// so the client can tell it is incomplete.
+// Panics with http.ErrAbortHandler itself are passed on as well.
+func (p *Pages) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ht := &headerTracker{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			var stack []byte
+			if p.OnPanic != nil || p.DevMode {
+				stack = debug.Stack()
+			}
+			if p.OnPanic != nil {
+				p.OnPanic(r, rec, stack)
+			}
+
+			if ht.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			// Errors are logged to ErrorLog by Render.
+			p.renderRecovered(w, r, rec, stack)
+		}()
+
+		next.ServeHTTP(ht, r)
+	})
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_recoveredMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		rec  interface{}
+		want string
+	}{
+		{"String", "foo", "foo"},
+		{"Error", errors.New("bar"), "bar"},
+		{"Other", 42, "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recoveredMessage(tt.rec); got != tt.want {
+				t.Errorf("recoveredMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Recover_Hijack(t *testing.T) {
+	p := &Pages{Tmpl: testTmpl}
+
+	srv := httptest.NewServer(p.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		brw.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+		brw.Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Pages.Recover() status = %v, want %v", resp.StatusCode, http.StatusTeapot)
+	}
+}
+
+func TestPages_Recover_informational(t *testing.T) {
+	p := &Pages{Tmpl: testTmpl, ErrorLog: log.New(ioutil.Discard, "", 0)}
+
+	srv := httptest.NewServer(p.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusEarlyHints)
+		panic("Oops")
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Pages.Recover() status = %v, want %v", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if got := string(body); got != "Generic template" {
+		t.Errorf("Pages.Recover() = %q, want %q", got, "Generic template")
+	}
+}
+
+func TestPages_RenderRecovered(t *testing.T) {
+	tests := []struct {
+		name      string
+		tmpl      string
+		devMode   bool
+		wantMsg   string
+		wantStack bool
+	}{
+		{
+			"Production",
+			`{{ .Message }}|`,
+			false,
+			DefaultRedactedMessage,
+			false,
+		},
+		{
+			"DevMode",
+			`{{ .Message }}|{{ .Stack }}`,
+			true,
+			"foo",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:    template.Must(template.New("error").Parse(tt.tmpl)),
+				DevMode: tt.devMode,
+			}
+			r := httptest.NewRequest("GET", "http://example.com/foo", nil)
+			w := httptest.NewRecorder()
+
+			func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						if err := p.RenderRecovered(w, r, rec); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}()
+				panic("foo")
+			}()
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Pages.RenderRecovered() status = %v, want %v", w.Code, http.StatusInternalServerError)
+			}
+
+			got := w.Body.String()
+			if !strings.HasPrefix(got, tt.wantMsg+"|") {
+				t.Errorf("Pages.RenderRecovered() = %v, want message %v", got, tt.wantMsg)
+			}
+			if hasStack := strings.Contains(got, "TestPages_RenderRecovered"); hasStack != tt.wantStack {
+				t.Errorf("Pages.RenderRecovered() stack = %v, want %v", hasStack, tt.wantStack)
+			}
+		})
+	}
+}
+
+func TestPages_Recover(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		wantCode  int
+		want      string
+		wantPanic interface{}
+		wantHook  bool
+	}{
+		{
+			"No panic",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Hello"))
+			},
+			http.StatusOK,
+			"Hello",
+			nil,
+			false,
+		},
+		{
+			"Panic",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic("foo")
+			},
+			http.StatusInternalServerError,
+			"500 " + DefaultRedactedMessage,
+			nil,
+			true,
+		},
+		{
+			"Panic after write",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Hel"))
+				panic("foo")
+			},
+			http.StatusOK,
+			"Hel",
+			http.ErrAbortHandler,
+			true,
+		},
+		{
+			"Abort",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			http.StatusOK,
+			"",
+			http.ErrAbortHandler,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				hookRec   interface{}
+				hookStack []byte
+			)
+			p := &Pages{
+				Tmpl: template.Must(template.New("error").Parse(`{{ .Status.Int }} {{ .Message }}`)),
+				OnPanic: func(r *http.Request, rec interface{}, stack []byte) {
+					hookRec, hookStack = rec, stack
+				},
+			}
+			h := p.Recover(tt.handler)
+			w := httptest.NewRecorder()
+
+			func() {
+				defer func() {
+					if got := recover(); got != tt.wantPanic {
+						t.Errorf("Pages.Recover() panic = %v, want %v", got, tt.wantPanic)
+					}
+				}()
+				h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/foo", nil))
+			}()
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.Recover() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Recover() = %q, want %q", got, tt.want)
+			}
+			if (hookRec != nil) != tt.wantHook {
+				t.Fatalf("Pages.Recover() OnPanic called = %v, want %v", hookRec != nil, tt.wantHook)
+			}
+			if tt.wantHook {
+				if hookRec != "foo" {
+					t.Errorf("Pages.Recover() OnPanic rec = %v, want %v", hookRec, "foo")
+				}
+				if !strings.Contains(string(hookStack), "TestPages_Recover") {
+					t.Errorf("Pages.Recover() OnPanic stack = %s, want the panicking handler", hookStack)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultRedactFrom is the lowest status for which messages are redacted,
+// when Pages.RedactFrom is 0.
+const DefaultRedactFrom Status = http.StatusInternalServerError
+
+// DefaultRedactedMessage is shown instead of a redacted message,
+// when Pages.RedactedMessage is empty.
+const DefaultRedactedMessage = "An unexpected error occurred"
+
+// redacted is a Provider with its message replaced,
+// for statuses that should not expose internal messages to the client.
+type redacted struct {
+	Provider
+	msg string
+}
+
+func (r *redacted) unwrap() Provider { return r.Provider }
+
+// Message implements Provider
+func (r *redacted) Message() string { return r.msg }
+
+// ErrText hides the error carried by the original Provider from `{{ .ErrText }}`.
+func (r *redacted) ErrText() string { return "" }
+
+func (r *redacted) String() string {
+	s := r.Status()
+	return fmt.Sprintf("%d %s: %s", s, s, r.msg)
+}
+
+// LogString returns the representation of the original Provider,
+// so the real message ends up in the logs.
+func (r *redacted) LogString() string { return LogString(r.Provider) }
+
+// redact replaces the message of dp, if its status is redacted,
+// and hides the error it carries from templates.
+// The real message is logged to ErrorLog.
+func (p *Pages) redact(dp Provider) Provider {
+	from := p.RedactFrom
+	if from == 0 {
+		from = DefaultRedactFrom
+	}
+	if p.DevMode || from < 0 || dp.Status() < from {
+		return dp
+	}
+
+	msg := p.RedactedMessage
+	if msg == "" {
+		msg = DefaultRedactedMessage
+	}
+	if (dp.Message() == "" || dp.Message() == msg) && errorOf(dp) == nil {
+		return dp
+	}
+
+	if p.ErrorLog != nil {
+		p.ErrorLog.Printf("ehtml: message redacted; while rendering %s", LogString(dp))
+	}
+	return &redacted{Provider: dp, msg: msg}
+}
+
+// truncated is a Provider with its message shortened to Pages.MaxMessageLen.
+type truncated struct {
+	Provider
+	msg string
+}
+
+func (t *truncated) unwrap() Provider { return t.Provider }
+
+// Message implements Provider
+func (t *truncated) Message() string { return t.msg }
+
+// ErrText returns the message of the wrapped error, for `{{ .ErrText }}`.
+func (t *truncated) ErrText() string { return errorText(t.Provider) }
+
+func (t *truncated) String() string {
+	s := t.Status()
+	return fmt.Sprintf("%d %s: %s", s, s, t.msg)
+}
+
+// LogString returns the representation of the original Provider,
+// so the full message ends up in the logs.
+func (t *truncated) LogString() string { return LogString(t.Provider) }
+
+// truncate shortens the message of dp to MaxMessageLen runes, if set.
+func (p *Pages) truncate(dp Provider) Provider {
+	if p.MaxMessageLen <= 0 {
+		return dp
+	}
+
+	msg := dp.Message()
+	if len(msg) <= p.MaxMessageLen {
+		return dp
+	}
+
+	var n int
+	for i := range msg {
+		if n == p.MaxMessageLen {
+			return &truncated{Provider: dp, msg: msg[:i] + "…"}
+		}
+		n++
+	}
+	return dp
+}
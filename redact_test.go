@@ -0,0 +1,140 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_redact(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *Pages
+		code Status
+		want string
+	}{
+		{
+			"Client error",
+			&Pages{},
+			http.StatusNotFound,
+			"Foo bar",
+		},
+		{
+			"Default",
+			&Pages{},
+			http.StatusInternalServerError,
+			DefaultRedactedMessage,
+		},
+		{
+			"Server error",
+			&Pages{RedactFrom: DefaultRedactFrom},
+			http.StatusInternalServerError,
+			DefaultRedactedMessage,
+		},
+		{
+			"RedactedMessage",
+			&Pages{RedactFrom: DefaultRedactFrom, RedactedMessage: "Oops"},
+			http.StatusBadGateway,
+			"Oops",
+		},
+		{
+			"RedactFrom",
+			&Pages{RedactFrom: http.StatusBadRequest},
+			http.StatusNotFound,
+			DefaultRedactedMessage,
+		},
+		{
+			"Disabled",
+			&Pages{RedactFrom: -1},
+			http.StatusInternalServerError,
+			"Foo bar",
+		},
+		{
+			"DevMode",
+			&Pages{RedactFrom: DefaultRedactFrom, DevMode: true},
+			http.StatusInternalServerError,
+			"Foo bar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.p.redact(&Data{Code: tt.code, Msg: "Foo bar"})
+
+			if msg := got.Message(); msg != tt.want {
+				t.Errorf("Pages.redact() Message = %v, want %v", msg, tt.want)
+			}
+			if s := got.String(); !strings.HasSuffix(s, ": "+tt.want) {
+				t.Errorf("Pages.redact() String = %v, want message %v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Render_redacted(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Pages{RedactFrom: DefaultRedactFrom, ErrorLog: log.New(&buf, "", 0)}
+	w := httptest.NewRecorder()
+
+	p.Render(w, &logData{Data{
+		Code: http.StatusInternalServerError,
+		Msg:  "secret",
+	}})
+
+	if body := w.Body.String(); strings.Contains(body, "secret") || !strings.Contains(body, DefaultRedactedMessage) {
+		t.Errorf("Pages.Render() = %v, want redacted message", body)
+	}
+
+	want := "ehtml: message redacted; while rendering 500 Internal Server Error: secret (user 42)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Pages.Render() log = %q, want %q", got, want)
+	}
+}
+
+func TestPages_truncate(t *testing.T) {
+	tests := []struct {
+		name string
+		max  int
+		msg  string
+		want string
+	}{
+		{"Unlimited", 0, "Foo bar", "Foo bar"},
+		{"Short", 10, "Foo bar", "Foo bar"},
+		{"Exact", 7, "Foo bar", "Foo bar"},
+		{"Long", 3, "Foo bar", "Foo…"},
+		{"Runes", 3, "Fóó bár", "Fóó…"},
+		{"Runes exact", 7, "Fóó bár", "Fóó bár"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{MaxMessageLen: tt.max}
+			dp := &Data{Code: http.StatusBadRequest, Msg: tt.msg}
+
+			got := p.truncate(dp)
+			if msg := got.Message(); msg != tt.want {
+				t.Errorf("Pages.truncate() = %q, want %q", msg, tt.want)
+			}
+			if LogString(got) != LogString(dp) {
+				t.Errorf("Pages.truncate() LogString = %q, want %q", LogString(got), LogString(dp))
+			}
+		})
+	}
+}
+
+func TestPages_Render_MaxMessageLen(t *testing.T) {
+	p := &Pages{MaxMessageLen: 10}
+	w := httptest.NewRecorder()
+
+	if err := p.Render(w, &Data{Code: http.StatusBadRequest, Msg: "Invalid path " + strings.Repeat("/x", 1<<20)}); err != nil {
+		t.Fatal(err)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "<p>Invalid pa…</p>") || w.Body.Len() > 1024 {
+		t.Errorf("Pages.Render() = %v, want truncated message", body)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// DefaultFlashMaxAge is used when Pages.FlashMaxAge is 0.
+const DefaultFlashMaxAge = 60
+
+// redirect the client to target, with a flash cookie if configured.
+func (p *Pages) redirect(w http.ResponseWriter, r *http.Request, target string, dp Provider) {
+	if p.FlashCookie != "" {
+		maxAge := p.FlashMaxAge
+		if maxAge == 0 {
+			maxAge = DefaultFlashMaxAge
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     p.FlashCookie,
+			Value:    url.QueryEscape(dp.Message()),
+			Path:     "/",
+			MaxAge:   maxAge,
+			Secure:   r.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// Flash returns the message from the flash cookie set by a redirect,
+// and deletes the cookie so the message is only shown once.
+// It returns an empty string if there is no flash message.
+func (p *Pages) Flash(w http.ResponseWriter, r *http.Request) string {
+	if p.FlashCookie == "" {
+		return ""
+	}
+
+	c, err := r.Cookie(p.FlashCookie)
+	if err != nil {
+		return ""
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   p.FlashCookie,
+		Path:   "/",
+		MaxAge: -1,
+	})
+
+	msg, err := url.QueryUnescape(c.Value)
+	if err != nil {
+		return ""
+	}
+	return msg
+}
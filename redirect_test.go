@@ -0,0 +1,127 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_Render_Redirect(t *testing.T) {
+	tests := []struct {
+		name        string
+		flashCookie string
+		code        Status
+		wantCode    int
+		wantCookie  string
+	}{
+		{
+			"Redirect",
+			"",
+			http.StatusUnauthorized,
+			http.StatusSeeOther,
+			"",
+		},
+		{
+			"Flash",
+			"flash",
+			http.StatusUnauthorized,
+			http.StatusSeeOther,
+			"Please+log+in%21",
+		},
+		{
+			"Not redirected",
+			"flash",
+			http.StatusNotFound,
+			http.StatusNotFound,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Redirects:   map[Status]string{http.StatusUnauthorized: "/login"},
+				FlashCookie: tt.flashCookie,
+			}
+			d := &Data{
+				Req:  httptest.NewRequest("GET", "http://example.com/foo", nil),
+				Code: tt.code,
+				Msg:  "Please log in!",
+			}
+
+			w := httptest.NewRecorder()
+			if err := p.Render(w, d); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("Pages.Render() status = %v, want %v", resp.StatusCode, tt.wantCode)
+			}
+
+			var cookie string
+			for _, c := range resp.Cookies() {
+				if c.Name == "flash" {
+					cookie = c.Value
+					if c.MaxAge != DefaultFlashMaxAge {
+						t.Errorf("Pages.Render() cookie MaxAge = %v, want %v", c.MaxAge, DefaultFlashMaxAge)
+					}
+				}
+			}
+			if cookie != tt.wantCookie {
+				t.Errorf("Pages.Render() cookie = %v, want %v", cookie, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestPages_Flash(t *testing.T) {
+	tests := []struct {
+		name        string
+		flashCookie string
+		cookie      *http.Cookie
+		want        string
+	}{
+		{
+			"Disabled",
+			"",
+			&http.Cookie{Name: "flash", Value: "Please+log+in%21"},
+			"",
+		},
+		{
+			"No cookie",
+			"flash",
+			nil,
+			"",
+		},
+		{
+			"Bad value",
+			"flash",
+			&http.Cookie{Name: "flash", Value: "%zz"},
+			"",
+		},
+		{
+			"Message",
+			"flash",
+			&http.Cookie{Name: "flash", Value: "Please+log+in%21"},
+			"Please log in!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{FlashCookie: tt.flashCookie}
+			r := httptest.NewRequest("GET", "http://example.com/login", nil)
+			if tt.cookie != nil {
+				r.AddCookie(tt.cookie)
+			}
+
+			w := httptest.NewRecorder()
+			if got := p.Flash(w, r); got != tt.want {
+				t.Errorf("Pages.Flash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
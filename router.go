@@ -0,0 +1,28 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "net/http"
+
+// NotFound returns a handler which renders the 404 page,
+// for the error hooks of routers such as go-chi:
+//
+//	r := chi.NewRouter()
+//	r.NotFound(p.NotFound())
+//	r.MethodNotAllowed(p.MethodNotAllowed())
+//
+// The handlers only depend on net/http, this package doesn't import any router.
+func (p *Pages) NotFound() http.HandlerFunc {
+	return p.HandlerFunc(http.StatusNotFound, "")
+}
+
+// MethodNotAllowed returns a handler which renders the 405 page.
+// An Allow header already set on the ResponseWriter, by the router or a middleware,
+// is sent along, unless removed by StripHeaders.
+// Note that go-chi doesn't expose the allowed methods of a route to custom handlers,
+// in which case a middleware should set the header, if needed.
+func (p *Pages) MethodNotAllowed() http.HandlerFunc {
+	return p.HandlerFunc(http.StatusMethodNotAllowed, "")
+}
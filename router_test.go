@@ -0,0 +1,49 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_routerHandlers(t *testing.T) {
+	p := &Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}"))}
+
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		allow     string
+		wantCode  int
+		wantBody  string
+		wantAllow string
+	}{
+		{"NotFound", p.NotFound(), "", http.StatusNotFound, "404 Not Found", ""},
+		{"MethodNotAllowed", p.MethodNotAllowed(), "", http.StatusMethodNotAllowed, "405 Method Not Allowed", ""},
+		{"Allow", p.MethodNotAllowed(), "GET, HEAD", http.StatusMethodNotAllowed, "405 Method Not Allowed", "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if tt.allow != "" {
+				w.Header().Set("Allow", tt.allow)
+			}
+
+			tt.handler(w, httptest.NewRequest(http.MethodPost, "/foo", nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("handler status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("handler body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("handler Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
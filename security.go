@@ -0,0 +1,87 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// securityHeaders are set by Pages.SecurityHeaders.
+var securityHeaders = [...]struct{ key, value string }{
+	{"X-Frame-Options", "DENY"},
+	{"Referrer-Policy", "no-referrer"},
+}
+
+func setSecurityHeaders(h http.Header) {
+	for _, sh := range securityHeaders {
+		h.Set(sh.key, sh.value)
+	}
+}
+
+// CSPNonce is the placeholder in Pages.CSP for the nonce of the response.
+const CSPNonce = "{nonce}"
+
+// nonceBytes is the number of random bytes in a nonce.
+const nonceBytes = 16
+
+// newNonce returns a base64 encoded nonce from a cryptographically secure random source.
+func newNonce() (string, error) {
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// usesNonce reports whether CSP contains the CSPNonce placeholder.
+func (p *Pages) usesNonce() bool {
+	return strings.Contains(p.CSP, CSPNonce)
+}
+
+// cspHeader returns the name and value of the Content-Security-Policy header,
+// or empty strings when CSP is not set.
+// CSPNonce is replaced by nonce.
+func (p *Pages) cspHeader(nonce string) (key, value string) {
+	if p.CSP == "" {
+		return "", ""
+	}
+
+	policy := strings.ReplaceAll(p.CSP, CSPNonce, nonce)
+
+	directives := []string{strings.TrimRight(strings.TrimSpace(policy), ";")}
+	if p.CSPReportURI != "" {
+		directives = append(directives, "report-uri "+p.CSPReportURI)
+	}
+	if p.CSPReportTo != "" {
+		directives = append(directives, "report-to "+p.CSPReportTo)
+	}
+
+	key = "Content-Security-Policy"
+	if p.CSPReportOnly {
+		key = "Content-Security-Policy-Report-Only"
+	}
+	return key, strings.Join(directives, "; ")
+}
+
+// setCSP sets the Content-Security-Policy header, if configured,
+// and returns the nonce it contains, if any.
+// When no nonce can be generated, the error is logged
+// and the policy is set with an empty nonce, which allows no inline content.
+func (p *Pages) setCSP(hs *headerSetter) (nonce string) {
+	if p.usesNonce() {
+		var err error
+		if nonce, err = newNonce(); err != nil && p.ErrorLog != nil {
+			p.ErrorLog.Printf("ehtml: CSP nonce: %v", err)
+		}
+	}
+
+	if key, value := p.cspHeader(nonce); key != "" {
+		hs.Set(key, value)
+	}
+	return nonce
+}
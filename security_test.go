@@ -0,0 +1,179 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_Render_CSP(t *testing.T) {
+	tests := []struct {
+		name      string
+		p         *Pages
+		wantKey   string
+		wantValue string
+	}{
+		{
+			"Not set",
+			&Pages{},
+			"",
+			"",
+		},
+		{
+			"Enforce",
+			&Pages{CSP: "default-src 'none'"},
+			"Content-Security-Policy",
+			"default-src 'none'",
+		},
+		{
+			"Report only",
+			&Pages{
+				CSP:           "default-src 'none';",
+				CSPReportOnly: true,
+				CSPReportURI:  "/csp-reports",
+				CSPReportTo:   "csp-endpoint",
+			},
+			"Content-Security-Policy-Report-Only",
+			"default-src 'none'; report-uri /csp-reports; report-to csp-endpoint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range []string{"Content-Security-Policy", "Content-Security-Policy-Report-Only"} {
+				var want string
+				if key == tt.wantKey {
+					want = tt.wantValue
+				}
+				if got := w.Header().Get(key); got != want {
+					t.Errorf("Pages.Render() header %s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPages_Render_CSPNonce(t *testing.T) {
+	render := func(p *Pages, d *Data) *httptest.ResponseRecorder {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		if err := p.Render(w, d); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	p := &Pages{
+		Tmpl: template.Must(template.New("error").Parse(`<style nonce="{{ .Nonce }}"></style>`)),
+		CSP:  "default-src 'none'; style-src 'nonce-{nonce}'",
+	}
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		w := render(p, &Data{Code: http.StatusNotFound})
+
+		var nonce string
+		if _, err := fmt.Sscanf(w.Header().Get("Content-Security-Policy"), "default-src 'none'; style-src 'nonce-%22s'", &nonce); err != nil {
+			t.Fatalf("Pages.Render() Content-Security-Policy = %q: %v", w.Header().Get("Content-Security-Policy"), err)
+		}
+		if want := `<style nonce="` + nonce + `"></style>`; w.Body.String() != want {
+			t.Errorf("Pages.Render() = %q, want %q", w.Body.String(), want)
+		}
+		nonces = append(nonces, nonce)
+	}
+	if nonces[0] == nonces[1] {
+		t.Errorf("Pages.Render() nonce %q reused", nonces[0])
+	}
+
+	t.Run("StyledPages", func(t *testing.T) {
+		p := StyledPages()
+		p.CSP = "style-src 'nonce-{nonce}'"
+
+		w := render(p, &Data{Code: http.StatusNotFound})
+		nonce := strings.TrimSuffix(strings.TrimPrefix(w.Header().Get("Content-Security-Policy"), "style-src 'nonce-"), "'")
+		if want := `<style nonce="` + nonce + `">`; !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Pages.Render() = %s\nwant containing %s", w.Body, want)
+		}
+
+		p.CSP = ""
+		if w := render(p, &Data{Code: http.StatusNotFound}); !strings.Contains(w.Body.String(), "<style>") {
+			t.Errorf("Pages.Render() = %s\nwant <style> without nonce", w.Body)
+		}
+	})
+
+	t.Run("StableETag", func(t *testing.T) {
+		p := &Pages{CSP: "style-src 'nonce-{nonce}'", StableETag: true}
+
+		if w := render(p, &Data{Code: http.StatusNotFound}); w.Header().Get("ETag") != "" {
+			t.Errorf("Pages.Render() ETag = %q, want none with a nonce", w.Header().Get("ETag"))
+		}
+	})
+}
+
+func TestPages_Render_SecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages *Pages
+		hdr   http.Header
+		want  http.Header
+	}{
+		{
+			"Default",
+			&Pages{},
+			nil,
+			http.Header{"X-Content-Type-Options": {"nosniff"}},
+		},
+		{
+			"Enabled",
+			&Pages{SecurityHeaders: true},
+			nil,
+			http.Header{
+				"X-Content-Type-Options": {"nosniff"},
+				"X-Frame-Options":        {"DENY"},
+				"Referrer-Policy":        {"no-referrer"},
+			},
+		},
+		{
+			"HeaderProvider",
+			&Pages{SecurityHeaders: true},
+			http.Header{"X-Frame-Options": {"SAMEORIGIN"}},
+			http.Header{
+				"X-Content-Type-Options": {"nosniff"},
+				"X-Frame-Options":        {"SAMEORIGIN"},
+				"Referrer-Policy":        {"no-referrer"},
+			},
+		},
+		{
+			"Disabled",
+			&Pages{DisableNoSniff: true},
+			nil,
+			http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if err := tt.pages.Render(w, &Data{Code: http.StatusNotFound, Hdr: tt.hdr}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range []string{"X-Content-Type-Options", "X-Frame-Options", "Referrer-Policy"} {
+				if got, want := w.Header().Get(key), tt.want.Get(key); got != want {
+					t.Errorf("Pages.Render() header %s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+// StackProvider can optionally be implemented by a Provider,
+// to carry a stack trace captured where the error occurred.
+// Data implements it for its Stack field.
+//
+// Templates show the stack with `{{ .Stack }}`, which is empty unless Pages.DevMode is set,
+// so stacks don't leak to clients in production.
+type StackProvider interface {
+	StackTrace() string
+}
+
+// StackTrace returns Stack.
+func (d *Data) StackTrace() string { return d.Stack }
+
+// stackOf returns the stack trace carried by dp, if any.
+func stackOf(dp Provider) string {
+	if sp, ok := optional[StackProvider](dp); ok {
+		return sp.StackTrace()
+	}
+	return ""
+}
+
+// Stack returns the stack trace of the Provider in DevMode, for `{{ .Stack }}`.
+// It is empty otherwise.
+func (pg *page) Stack() string { return pg.stack }
@@ -0,0 +1,100 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_Render_Stack(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{ .Message }}|{{ .Stack }}`))
+
+	tests := []struct {
+		name    string
+		devMode bool
+		code    Status
+		want    string
+	}{
+		{"DevMode", true, http.StatusInternalServerError, "Oops|goroutine 1"},
+		{"Production", false, http.StatusBadRequest, "Oops|"},
+		{"Production redacted", false, http.StatusInternalServerError, DefaultRedactedMessage + "|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: tmpl, DevMode: tt.devMode, RedactFrom: DefaultRedactFrom}
+			w := httptest.NewRecorder()
+
+			if err := p.Render(w, &Data{Code: tt.code, Msg: "Oops", Stack: "goroutine 1"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPages_Handle_Stack(t *testing.T) {
+	tests := []struct {
+		name      string
+		devMode   bool
+		err       error
+		wantStack bool
+	}{
+		{"DevMode", true, errors.New("oops"), true},
+		{"DevMode without error", true, nil, false},
+		{"Production", false, errors.New("oops"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:    template.Must(template.New("error").Parse(`{{ .Stack }}`)),
+				DevMode: tt.devMode,
+			}
+			w := httptest.NewRecorder()
+
+			if err := p.Handle(w, nil, http.StatusInternalServerError, "", tt.err); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(w.Body.String(), "TestPages_Handle_Stack"); got != tt.wantStack {
+				t.Errorf("Pages.Handle() stack = %v, want %v:\n%s", got, tt.wantStack, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPages_Render_FallbackTmpl_Stack(t *testing.T) {
+	tests := []struct {
+		name    string
+		devMode bool
+		want    string
+	}{
+		{"DevMode", true, "fallback stack=goroutine 1"},
+		{"Production", false, "fallback stack="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{
+				Tmpl:         template.Must(template.New("error").Parse("{{ .Missing }}")),
+				FallbackTmpl: template.Must(template.New("fallback").Parse("fallback stack={{ .Stack }}")),
+				DevMode:      tt.devMode,
+			}
+			w := httptest.NewRecorder()
+
+			d := &Data{Code: http.StatusInternalServerError, Msg: "Oops", Stack: "goroutine 1 [running]:"}
+			if err := p.Render(w, d); !errors.Is(err, ErrTemplateExec) {
+				t.Fatalf("Pages.Render() err = %v, want %v", err, ErrTemplateExec)
+			}
+			if got := w.Body.String(); !strings.HasPrefix(got, tt.want) || (!tt.devMode && got != tt.want) {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
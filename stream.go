@@ -0,0 +1,69 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// RenderStream renders the html page for dp like Render,
+// but executes the template directly into w after writing the status and headers.
+// It is meant for very large pages, such as diagnostic dumps,
+// which would take a lot of memory to buffer.
+//
+// This is the opposite tradeoff of Render: if template execution fails halfway,
+// the client receives a partial page with the original status,
+// as it is too late to send RenderError. The error is returned and logged as usual.
+// Without buffering, there is no Content-Length and the response is chunked,
+// and MaxBufferBytes, Sanitizer, TreatEmptyAsError, Encoders, compression and ETags don't apply.
+// The page is always html, regardless of the Accept header.
+func (p *Pages) RenderStream(w http.ResponseWriter, dp Provider) error {
+	start := time.Now()
+	dp = p.transform(dp)
+	cw := &countWriter{ResponseWriter: w}
+
+	set, lang := p.templateSet(dp.Request())
+	name, err := p.renderStream(cw, set, lang, dp)
+	return p.rendered(dp, name, start, cw.n, err)
+}
+
+func (p *Pages) renderStream(w http.ResponseWriter, set *template.Template, lang string, dp Provider) (string, error) {
+	if headersSent(w) {
+		return "", ErrHeadersSent
+	}
+	bypassIntercept(w)
+	p.commonHeaders(w.Header(), lang, dp)
+
+	if target, ok := p.Redirects[dp.Status()]; ok && dp.Request() != nil {
+		p.redirect(w, dp.Request(), target, dp)
+		return "", nil
+	}
+
+	data := p.pageHeaders(w.Header(), dp, lang)
+	p.setContentType(w.Header(), dp, formatHTML, nil)
+	p.writeHeader(w, dp)
+
+	tmpl := p.lookupPage(set, dp)
+	name := tmplName(tmpl)
+	p.fallback(dp.Status(), name)
+
+	if r := dp.Request(); r != nil && r.Method == http.MethodHead {
+		return name, nil
+	}
+
+	var err error
+	if tmpl == defTmpl {
+		err = executeDefault(w, dp, data)
+	} else {
+		err = p.executeLayout(w, set, tmpl, data)
+	}
+	if err != nil {
+		return name, wrapKind(ErrTemplateExec, fmt.Errorf("ehtml RenderStream template: %w", err))
+	}
+	return name, nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPages_RenderStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    *Pages
+		method   string
+		wantBody string
+		wantErr  string
+	}{
+		{
+			"Template",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}"))},
+			http.MethodGet,
+			"404 Foo bar",
+			"",
+		},
+		{
+			"Partial",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Missing }}"))},
+			http.MethodGet,
+			"404 ",
+			"ehtml RenderStream template: ",
+		},
+		{
+			"Head",
+			&Pages{Tmpl: template.Must(template.New("error").Parse("{{ .Status.Int }} {{ .Message }}"))},
+			http.MethodHead,
+			"",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			d := &Data{Req: httptest.NewRequest(tt.method, "/", nil), Code: http.StatusNotFound, Msg: "Foo bar"}
+
+			err := tt.pages.RenderStream(w, d)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.HasPrefix(err.Error(), tt.wantErr)) {
+				t.Fatalf("Pages.RenderStream() err = %v, want %q", err, tt.wantErr)
+			}
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Pages.RenderStream() status = %v, want %v", w.Code, http.StatusNotFound)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("Pages.RenderStream() Content-Type = %q", got)
+			}
+			if got := w.Header().Get("Content-Length"); got != "" {
+				t.Errorf("Pages.RenderStream() Content-Length = %q, want none", got)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("Pages.RenderStream() = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPages_RenderStream_default(t *testing.T) {
+	p := &Pages{Lang: "nl"}
+	d := &Data{Code: http.StatusNotFound, Msg: "Foo bar"}
+
+	want := httptest.NewRecorder()
+	if err := p.Render(want, d); err != nil {
+		t.Fatal(err)
+	}
+
+	got := httptest.NewRecorder()
+	if err := p.RenderStream(got, d); err != nil {
+		t.Fatal(err)
+	}
+	if got.Body.String() != want.Body.String() {
+		t.Errorf("Pages.RenderStream() =\n%s\nwant\n%s", got.Body, want.Body)
+	}
+	if !strings.Contains(got.Body.String(), `<html lang="nl">`) {
+		t.Errorf("Pages.RenderStream() = %s, want Lang", got.Body)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import "net/http"
+
+// PlaintextMessage is the message of the page rendered by RequireTLS.
+const PlaintextMessage = "This server requires a secure connection. Please use https:// instead."
+
+// IsPlaintext reports whether r was received without TLS.
+// It is only meaningful on servers terminating TLS themselves:
+// behind a TLS terminating proxy, every request is plaintext.
+func IsPlaintext(r *http.Request) bool {
+	return !(&Data{Req: r}).IsTLS()
+}
+
+// RequireTLS returns a handler which serves plaintext requests,
+// as reported by IsPlaintext, with a "426 Upgrade Required" page
+// and passes all other requests to next.
+// This helps users which reached a https-only server over plain http,
+// for example because of a misconfigured listener or port.
+func (p *Pages) RequireTLS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsPlaintext(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Upgrade", "TLS/1.2, HTTP/1.1")
+		w.Header().Set("Connection", "Upgrade")
+
+		// Errors are logged to ErrorLog by Render.
+		p.Handle(w, r, http.StatusUpgradeRequired, PlaintextMessage, nil)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_RequireTLS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	h := (&Pages{Tmpl: testTmpl}).RequireTLS(next)
+
+	tests := []struct {
+		name        string
+		url         string
+		wantCode    int
+		want        string
+		wantUpgrade string
+	}{
+		{
+			"Plaintext",
+			"http://example.com/foo",
+			http.StatusUpgradeRequired,
+			"Generic template",
+			"TLS/1.2, HTTP/1.1",
+		},
+		{
+			"TLS",
+			"https://example.com/foo",
+			http.StatusOK,
+			"OK",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Pages.RequireTLS() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("Pages.RequireTLS() = %v, want %v", got, tt.want)
+			}
+			if got := w.Header().Get("Upgrade"); got != tt.wantUpgrade {
+				t.Errorf("Pages.RequireTLS() Upgrade = %v, want %v", got, tt.wantUpgrade)
+			}
+		})
+	}
+}
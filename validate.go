@@ -0,0 +1,206 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Validate checks Tmpl, or the set from SetTemplate or WatchFS,
+// and the sets of Locales for mistakes
+// which would otherwise only show at request time,
+// when a status silently falls back to another page or DefaultTmpl.
+// It is meant to be called at startup, returning all problems found:
+//
+//   - A set must define the "error" template, or a page for each of codes.
+//   - Templates must only reference templates defined in the set.
+//   - Partials used as `{{ partial "head" . }}` must be defined,
+//     as "head" or as a status variant such as "head-500".
+//   - Templates named like a page must not be empty.
+//
+// Validate returns nil when Tmpl is nil and there are no Locales,
+// as DefaultTmpl is used for all statuses.
+func (p *Pages) Validate(codes ...Status) error {
+	var errs []error
+
+	base := p.base()
+	if base != nil {
+		for _, err := range validateSet(base, codes) {
+			errs = append(errs, fmt.Errorf("ehtml Validate: %w", err))
+		}
+	}
+
+	names := make([]string, 0, len(p.Locales))
+	for name := range p.Locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		set := p.Locales[name]
+		if set == nil {
+			errs = append(errs, fmt.Errorf("ehtml Validate: Locales %q: nil template set", name))
+			continue
+		}
+		if set == base {
+			continue
+		}
+		for _, err := range validateSet(set, codes) {
+			errs = append(errs, fmt.Errorf("ehtml Validate: Locales %q: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateSet(set *template.Template, codes []Status) []error {
+	var (
+		errs     []error
+		defined  = make(map[string]bool)
+		refs     = make(map[string]bool)
+		partials = make(map[string]bool)
+	)
+
+	tmpls := set.Templates()
+	sort.Slice(tmpls, func(i, j int) bool { return tmpls[i].Name() < tmpls[j].Name() })
+
+	for _, t := range tmpls {
+		if t.Tree == nil || t.Tree.Root == nil {
+			if isPageName(t.Name()) {
+				errs = append(errs, fmt.Errorf("template %q is empty", t.Name()))
+			}
+			continue
+		}
+
+		defined[t.Name()] = true
+		templateRefs(t.Tree.Root, refs, partials)
+	}
+
+	if !defined["error"] {
+		if len(codes) == 0 {
+			errs = append(errs, errors.New(`no "error" template`))
+		}
+		for _, code := range codes {
+			if lookupSet(set, code) == nil {
+				errs = append(errs, fmt.Errorf("no template for status %d and no \"error\" template", code))
+			}
+		}
+	}
+
+	missing := make([]string, 0, len(refs))
+	for name := range refs {
+		if !defined[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, name := range missing {
+		errs = append(errs, fmt.Errorf("template %q is referenced but not defined", name))
+	}
+
+	missing = missing[:0]
+	for name := range partials {
+		if !definesPartial(defined, name) {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, name := range missing {
+		errs = append(errs, fmt.Errorf("partial %q is used but not defined", name))
+	}
+
+	return errs
+}
+
+// definesPartial reports whether defined holds name or one of its status variants.
+func definesPartial(defined map[string]bool, name string) bool {
+	if defined[name] {
+		return true
+	}
+	for d := range defined {
+		if strings.HasPrefix(d, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// isPageName reports whether name is looked up by Render for a status,
+// as documented on Pages.
+func isPageName(name string) bool {
+	name = strings.TrimSuffix(name, "."+formatHTML)
+
+	switch {
+	case name == "error", name == "timeout", isStatusName(name):
+		return true
+	case len(name) == 3 && name[0] >= '1' && name[0] <= '9' && name[1:] == "xx":
+		return true
+	}
+	return false
+}
+
+func isStatusName(name string) bool {
+	n, err := strconv.Atoi(name)
+	return err == nil && n > 0 && Status(n).toA() == name
+}
+
+// templateRefs adds the names of the templates invoked from node to refs,
+// and the constant names passed to the partial func to partials.
+func templateRefs(node parse.Node, refs, partials map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			templateRefs(c, refs, partials)
+		}
+	case *parse.ActionNode:
+		partialRefs(n.Pipe, partials)
+	case *parse.IfNode:
+		partialRefs(n.Pipe, partials)
+		templateRefs(n.List, refs, partials)
+		templateRefs(n.ElseList, refs, partials)
+	case *parse.RangeNode:
+		partialRefs(n.Pipe, partials)
+		templateRefs(n.List, refs, partials)
+		templateRefs(n.ElseList, refs, partials)
+	case *parse.WithNode:
+		partialRefs(n.Pipe, partials)
+		templateRefs(n.List, refs, partials)
+		templateRefs(n.ElseList, refs, partials)
+	case *parse.TemplateNode:
+		refs[n.Name] = true
+		partialRefs(n.Pipe, partials)
+	}
+}
+
+// partialRefs adds the constant names passed to the partial func in pipe to partials.
+func partialRefs(pipe *parse.PipeNode, partials map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) > 1 {
+			id, ok := cmd.Args[0].(*parse.IdentifierNode)
+			if s, isStr := cmd.Args[1].(*parse.StringNode); ok && isStr && id.Ident == "partial" {
+				partials[s.Text] = true
+			}
+		}
+		for _, arg := range cmd.Args {
+			if p, ok := arg.(*parse.PipeNode); ok {
+				partialRefs(p, partials)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPages_Validate(t *testing.T) {
+	parse := func(text string) *template.Template {
+		return template.Must(template.New("").Parse(text))
+	}
+	parsePartials := func(text string) *template.Template {
+		return template.Must(AddPartialFunc(template.New("")).Parse(text))
+	}
+
+	tests := []struct {
+		name    string
+		pages   *Pages
+		codes   []Status
+		wantErr []string
+	}{
+		{
+			"Nil Tmpl",
+			&Pages{},
+			nil,
+			nil,
+		},
+		{
+			"Defaults",
+			DefaultPages(),
+			nil,
+			nil,
+		},
+		{
+			"Valid",
+			&Pages{Tmpl: parse(`
+				{{ define "head" }}<title>{{ .String }}</title>{{ end }}
+				{{ define "error" }}{{ template "head" . }}{{ end }}
+				{{ define "404" }}{{ if .Message }}{{ template "head" . }}{{ end }}{{ end }}
+				{{ define "4xx" }}{{ block "body" . }}{{ .Message }}{{ end }}{{ end }}
+				{{ define "timeout" }}{{ end }}
+				{{ define "error.html" }}{{ end }}
+				{{ define "410.html" }}{{ end }}
+			`)},
+			nil,
+			nil,
+		},
+		{
+			"Codes",
+			&Pages{Tmpl: parse(`{{ define "404" }}{{ end }}{{ define "5xx" }}{{ end }}`)},
+			[]Status{404, 503},
+			nil,
+		},
+		{
+			"No error",
+			&Pages{Tmpl: parse(`{{ define "404" }}{{ end }}`)},
+			nil,
+			[]string{`ehtml Validate: no "error" template`},
+		},
+		{
+			"Missing codes",
+			&Pages{Tmpl: parse(`{{ define "404" }}{{ end }}`)},
+			[]Status{404, 410, 500},
+			[]string{
+				`ehtml Validate: no template for status 410 and no "error" template`,
+				`ehtml Validate: no template for status 500 and no "error" template`,
+			},
+		},
+		{
+			"Partials and named pages",
+			&Pages{Tmpl: parsePartials(`
+				{{ define "error" }}{{ partial "head" . }}{{ end }}
+				{{ define "head" }}{{ end }}
+				{{ define "head-500" }}<script></script>{{ end }}
+				{{ define "maintenance" }}{{ end }}
+			`)},
+			nil,
+			nil,
+		},
+		{
+			"Undefined partials",
+			&Pages{Tmpl: parsePartials(`{{ define "error" }}{{ partial "head" . }}{{ if .Message }}{{ (partial "fotter" .) | printf "%s" }}{{ end }}{{ partial "nav" . }}{{ end }}{{ define "nav-5xx" }}{{ end }}`)},
+			nil,
+			[]string{
+				`ehtml Validate: partial "fotter" is used but not defined`,
+				`ehtml Validate: partial "head" is used but not defined`,
+			},
+		},
+		{
+			"Undefined",
+			&Pages{Tmpl: parse(`{{ define "error" }}{{ with .Message }}{{ template "fotter" . }}{{ else }}{{ template "header" }}{{ end }}{{ end }}`)},
+			nil,
+			[]string{
+				`ehtml Validate: template "fotter" is referenced but not defined`,
+				`ehtml Validate: template "header" is referenced but not defined`,
+			},
+		},
+		{
+			"Empty page",
+			&Pages{Tmpl: template.New("error")},
+			nil,
+			[]string{
+				`ehtml Validate: template "error" is empty`,
+				`ehtml Validate: no "error" template`,
+			},
+		},
+		{
+			"Locales",
+			&Pages{
+				Tmpl: parse(`{{ define "error" }}{{ end }}`),
+				Locales: map[string]*template.Template{
+					"fr": parse(`{{ define "error" }}{{ end }}`),
+					"nl": parse(`{{ define "404" }}{{ end }}`),
+					"de": nil,
+				},
+			},
+			nil,
+			[]string{
+				`ehtml Validate: Locales "de": nil template set`,
+				`ehtml Validate: Locales "nl": no "error" template`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pages.Validate(tt.codes...)
+
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if !reflect.DeepEqual(got, tt.wantErr) {
+				t.Errorf("Pages.Validate() =\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.wantErr, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseFS_Validate(t *testing.T) {
+	p, err := ParseDir("testdata/templates", "*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// watcher holds a template set parsed from a file system,
+// which is parsed again when the files change.
+type watcher struct {
+	fsys     fs.FS
+	patterns []string
+	funcs    template.FuncMap
+
+	mu   sync.Mutex
+	tmpl *template.Template
+	// stamp of the files tmpl was parsed from.
+	stamp string
+}
+
+// WatchFS parses the template files in fsys matching the patterns, like ParseFS,
+// and uses them instead of Tmpl.
+//
+// In DevMode, the files are checked for changes on every render
+// and parsed again when a modification time or size changed,
+// so edits show without restarting the server.
+// This globs and stats all files for every page, which makes it for development only.
+// Without DevMode, the templates are parsed once and not reloaded.
+// When parsing fails on reload, the error is logged to ErrorLog
+// and the previous templates stay in use.
+//
+// The templates can use the funcs of ParseFS and those added with Funcs before.
+// WatchFS must be called before pages are rendered.
+func (p *Pages) WatchFS(fsys fs.FS, patterns ...string) error {
+	w := &watcher{fsys: fsys, patterns: patterns, funcs: p.funcs}
+	if err := w.reload(); err != nil {
+		return fmt.Errorf("ehtml WatchFS: %w", err)
+	}
+
+	p.watch = w
+	return nil
+}
+
+// set returns the current template set, reloaded first in DevMode.
+func (w *watcher) set(p *Pages) *template.Template {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if p.DevMode {
+		if err := w.reload(); err != nil && p.ErrorLog != nil {
+			p.ErrorLog.Printf("ehtml WatchFS: %v; keeping the previous templates", err)
+		}
+	}
+	return w.tmpl
+}
+
+// current returns the template set, without reloading.
+func (w *watcher) current() *template.Template {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.tmpl
+}
+
+// reload parses the files if their stamp changed since the last parse.
+func (w *watcher) reload() error {
+	stamp, err := w.stampFiles()
+	if err != nil {
+		return err
+	}
+	if w.tmpl != nil && stamp == w.stamp {
+		return nil
+	}
+
+	tmpl, err := parseFS(w.fsys, w.patterns, w.funcs)
+	if err != nil {
+		return err
+	}
+
+	w.tmpl, w.stamp = tmpl, stamp
+	return nil
+}
+
+// stampFiles returns the names, modification times and sizes of the matching files.
+func (w *watcher) stampFiles() (string, error) {
+	var b strings.Builder
+
+	for _, pattern := range w.patterns {
+		files, err := fs.Glob(w.fsys, pattern)
+		if err != nil {
+			return "", err
+		}
+
+		for _, file := range files {
+			fi, err := fs.Stat(w.fsys, file)
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(file)
+			b.WriteByte(0)
+			b.WriteString(strconv.FormatInt(fi.ModTime().UnixNano(), 10))
+			b.WriteByte(0)
+			b.WriteString(strconv.FormatInt(fi.Size(), 10))
+			b.WriteByte(0)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestPages_WatchFS(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		devMode  bool
+		edit     string
+		wantBody string
+		wantLog  string
+	}{
+		{
+			"DevMode",
+			true,
+			"Edited {{ .Status.Int }}",
+			"Edited 404",
+			"",
+		},
+		{
+			"Production",
+			false,
+			"Edited {{ .Status.Int }}",
+			"Original 404",
+			"",
+		},
+		{
+			"Parse error",
+			true,
+			"Edited {{ .Status.Int }",
+			"Original 404",
+			"ehtml WatchFS: error.html: template: error:1: unexpected \"}\" in operand; keeping the previous templates\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"error.html": {Data: []byte("Original {{ .Status.Int }}"), ModTime: now},
+			}
+
+			var buf bytes.Buffer
+			p := &Pages{DevMode: tt.devMode, ErrorLog: log.New(&buf, "", 0)}
+			if err := p.WatchFS(fsys, "*.html"); err != nil {
+				t.Fatal(err)
+			}
+
+			render := func() string {
+				w := httptest.NewRecorder()
+				if err := p.Render(w, &Data{Code: http.StatusNotFound}); err != nil {
+					t.Fatal(err)
+				}
+				return w.Body.String()
+			}
+
+			if got := render(); got != "Original 404" {
+				t.Fatalf("Pages.Render() = %q, want %q", got, "Original 404")
+			}
+
+			fsys["error.html"] = &fstest.MapFile{Data: []byte(tt.edit), ModTime: now.Add(time.Second)}
+
+			if got := render(); got != tt.wantBody {
+				t.Errorf("Pages.Render() = %q, want %q", got, tt.wantBody)
+			}
+			if got := buf.String(); got != tt.wantLog {
+				t.Errorf("Pages.Render() log = %q, want %q", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestPages_WatchFS_error(t *testing.T) {
+	fsys := fstest.MapFS{
+		"error.html": {Data: []byte("{{ .Status.Int }")},
+	}
+
+	p := &Pages{}
+	err := p.WatchFS(fsys, "*.html")
+	if err == nil || !strings.HasPrefix(err.Error(), "ehtml WatchFS: error.html") {
+		t.Fatalf("Pages.WatchFS() error = %v", err)
+	}
+	if p.watch != nil {
+		t.Error("Pages.WatchFS() set watch on error")
+	}
+}
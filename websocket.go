@@ -0,0 +1,42 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RenderUpgradeError renders an error page for a failed WebSocket upgrade,
+// such as a bad origin or unsupported protocol version.
+// The reason, if not nil, is used as message.
+// For 426 Upgrade Required, the "Upgrade: websocket" header is set if missing.
+//
+// It can only be used before the connection is hijacked.
+// After that, nothing can be written through w
+// and the returned error wraps http.ErrHijacked.
+// When using gorilla/websocket, set it as the Upgrader's Error func,
+// which is only called before hijacking:
+//
+//	upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+//		if err := p.RenderUpgradeError(w, r, status, reason); err != nil {
+//			log.Println(err)
+//		}
+//	}
+func (p *Pages) RenderUpgradeError(w http.ResponseWriter, r *http.Request, status int, reason error) error {
+	var msg string
+	if reason != nil {
+		msg = reason.Error()
+	}
+
+	if status == http.StatusUpgradeRequired && w.Header().Get("Upgrade") == "" {
+		w.Header().Set("Upgrade", "websocket")
+	}
+
+	if err := p.Handle(w, r, Status(status), msg, nil); err != nil {
+		return fmt.Errorf("ehtml RenderUpgradeError: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2020, Mohlmann Solutions SRL. All rights reserved.
+// Use of this source code is governed by a License that can be found in the LICENSE file.
+// SPDX-License-Identifier: BSD-3-Clause
+
+package ehtml
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPages_RenderUpgradeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		reason      error
+		wantMsg     string
+		wantUpgrade string
+	}{
+		{
+			"Bad origin",
+			http.StatusForbidden,
+			errors.New("websocket: request origin not allowed"),
+			"websocket: request origin not allowed",
+			"",
+		},
+		{
+			"Upgrade required",
+			http.StatusUpgradeRequired,
+			nil,
+			"",
+			"websocket",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pages{Tmpl: testTmpl}
+			r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+
+			w := httptest.NewRecorder()
+			if err := p.RenderUpgradeError(w, r, tt.status, tt.reason); err != nil {
+				t.Fatal(err)
+			}
+
+			resp := w.Result()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Pages.RenderUpgradeError() status = %v, want %v", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Upgrade"); got != tt.wantUpgrade {
+				t.Errorf("Pages.RenderUpgradeError() Upgrade = %v, want %v", got, tt.wantUpgrade)
+			}
+		})
+	}
+}
+
+func TestPages_RenderUpgradeError_Hijacked(t *testing.T) {
+	p := &Pages{}
+	errc := make(chan error, 1)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+
+		errc <- p.RenderUpgradeError(w, r, http.StatusBadRequest, nil)
+	}))
+	// Silence the hijacked connection warnings.
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	if resp, err := http.Get(srv.URL); err == nil {
+		resp.Body.Close()
+	}
+
+	if err := <-errc; !errors.Is(err, http.ErrHijacked) {
+		t.Errorf("Pages.RenderUpgradeError() error = %v, wantErr %v", err, http.ErrHijacked)
+	}
+}